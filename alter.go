@@ -0,0 +1,550 @@
+package sqlparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Apply runs the CREATE TABLE, CREATE INDEX, ALTER TABLE, DROP TABLE and
+// DROP INDEX statements of migrations against a copy of the schema and
+// returns the resulting changes, the schema itself is left untouched.
+// Other statements, e.g. SET, INSERT or CREATE VIEW, are skipped.
+func (s Schema) Apply(migrations io.Reader) (*SchemaDiff, error) {
+	to, err := s.apply(migrations)
+	if err != nil {
+		return nil, err
+	}
+	return s.Diff(to), nil
+}
+
+// apply returns a copy of the schema with the statements of r applied
+func (s Schema) apply(r io.Reader) (Schema, error) {
+	schema := s.Clone()
+	p := NewParser(r)
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		switch tok {
+		case EOF:
+			return schema, nil
+		case SEMI_COLON, ANNOTATION:
+			continue
+		case CREATE:
+			switch tok1, _ := p.scanIgnoreWhitespace(); tok1 {
+			case TABLE:
+				table, err := p.scanCreateTable()
+				if err != nil {
+					return nil, p.errorAt(err)
+				}
+				if schema[table.Name] != nil {
+					return nil, p.errorAt(fmt.Errorf("table %q already exists", table.Name))
+				}
+				schema[table.Name] = table
+			case UNIQUE, FULLTEXT, SPATIAL, KEY:
+				if err := p.scanCreateIndex(schema, tok1); err != nil {
+					return nil, p.errorAt(err)
+				}
+			default: // e.g. CREATE VIEW or CREATE TRIGGER
+				p.unscan()
+				if !p.skipStatement() {
+					return schema, nil
+				}
+			}
+		case DROP:
+			switch tok1, _ := p.scanIgnoreWhitespace(); tok1 {
+			case TABLE:
+				p.unscan()
+				if err := p.scanDropTable(schema); err != nil {
+					return nil, p.errorAt(err)
+				}
+			case KEY:
+				if err := p.scanDropIndex(schema); err != nil {
+					return nil, p.errorAt(err)
+				}
+			default: // e.g. DROP VIEW
+				p.unscan()
+				if !p.skipStatement() {
+					return schema, nil
+				}
+			}
+		case ALTER:
+			if err := p.scanAlterTable(schema); err != nil {
+				return nil, p.errorAt(err)
+			}
+		default: // e.g. SET FOREIGN_KEY_CHECKS=0 or INSERT
+			if tok == IDENT && strings.EqualFold(lit, "DELIMITER") {
+				if err := p.scanDelimiter(); err != nil {
+					return nil, p.errorAt(err)
+				}
+				continue
+			}
+			p.unscan()
+			if !p.skipStatement() {
+				return schema, nil
+			}
+		}
+	}
+}
+
+// scanDropTable scans TABLE [IF EXISTS] name [, name] following DROP and
+// removes the tables from schema. As MySQL does with foreign key checks
+// on, it refuses to drop a table still referenced by a table it keeps.
+func (p *Parser) scanDropTable(schema Schema) error {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != TABLE {
+		return fmt.Errorf("found DROP %q, expected DROP TABLE", lit)
+	}
+	var ifExists bool
+	if tok, _ := p.scanIgnoreWhitespace(); tok == IF {
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != EXISTS {
+			return fmt.Errorf("found %q, expected IF EXISTS", lit1)
+		}
+		ifExists = true
+	} else {
+		p.unscan()
+	}
+	dropped := make(map[string]bool)
+	for {
+		_, name, err := p.scanQualifiedIdent()
+		if err != nil {
+			return err
+		}
+		if schema[name] != nil {
+			dropped[name] = true
+		} else if !ifExists {
+			return fmt.Errorf("unknown table %q", name)
+		}
+		tok, lit := p.scanIgnoreWhitespace()
+		if tok == SEMI_COLON || tok == EOF {
+			break
+		} else if tok != COMMA {
+			return fmt.Errorf("found %q, expected , or ;", lit)
+		}
+	}
+	for _, name := range tableNames(schema) {
+		if dropped[name] {
+			continue
+		}
+		for _, key := range schema[name].constraintNames() {
+			if cos := schema[name].Constraints[key]; dropped[cos.TableName] {
+				return fmt.Errorf("table %q is referenced by constraint %q of table %q", cos.TableName, key, name)
+			}
+		}
+	}
+	for name := range dropped {
+		delete(schema, name)
+	}
+	return nil
+}
+
+// scanDropIndex scans name ON table following DROP INDEX and removes the
+// index from its table in schema
+func (p *Parser) scanDropIndex(schema Schema) error {
+	tok, name := p.scanIgnoreWhitespace()
+	if tok != IDENT && tok != PRIMARY {
+		return fmt.Errorf("found %q, expected index name", name)
+	}
+	if tok == PRIMARY {
+		name = "PRIMARY"
+	}
+	if tok, lit := p.scanIgnoreWhitespace(); tok != ON {
+		return fmt.Errorf("found %q, expected ON", lit)
+	}
+	_, tableName, err := p.scanQualifiedIdent()
+	if err != nil {
+		return err
+	}
+	table := schema[tableName]
+	if table == nil {
+		return fmt.Errorf("table %q not found", tableName)
+	}
+	return table.dropIndex(name)
+}
+
+// scanAlterTable scans TABLE name followed by a comma separated list of
+// ADD [COLUMN], DROP [COLUMN], MODIFY [COLUMN] and CONVERT TO CHARACTER SET
+// operations and applies them to the table in schema, DISCARD TABLESPACE
+// and IMPORT TABLESPACE are skipped
+func (p *Parser) scanAlterTable(schema Schema) error {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != TABLE {
+		return fmt.Errorf("found ALTER %q, expected ALTER TABLE", lit)
+	}
+	_, name, err := p.scanQualifiedIdent()
+	if err != nil {
+		return err
+	}
+	table := schema[name]
+	if table == nil {
+		return fmt.Errorf("table %q not found", name)
+	}
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		switch tok {
+		case ADD, MODIFY:
+			tok1, _ := p.scanIgnoreWhitespace()
+			if tok == ADD && (tok1 == PRIMARY || tok1 == UNIQUE || tok1 == FULLTEXT || tok1 == SPATIAL || tok1 == KEY) {
+				if err := p.scanAddIndex(table, tok1); err != nil {
+					return err
+				}
+				break
+			}
+			if tok == ADD && (tok1 == CONSTRAINT || tok1 == FOREIGN || tok1 == CHECK) {
+				p.unscan()
+				if err := p.scanAddConstraint(table); err != nil {
+					return err
+				}
+				break
+			}
+			if tok1 != COLUMN {
+				p.unscan()
+			}
+			column, err := p.scanColumn(table)
+			if err != nil {
+				return err
+			}
+			_, exists := table.Columns[column.Name]
+			if tok == ADD && exists {
+				return fmt.Errorf("duplicate column %q in table %q", column.Name, name)
+			}
+			if tok == MODIFY && !exists {
+				return fmt.Errorf("column %q not found in table %q", column.Name, name)
+			}
+			table.addColumn(column)
+			if err := p.scanColumnPosition(table, column.Name); err != nil {
+				return err
+			}
+		case DROP:
+			if err := p.scanAlterDrop(table); err != nil {
+				return err
+			}
+		case CONVERT:
+			if err := p.scanConvertCharset(table); err != nil {
+				return err
+			}
+		case LOCK:
+			if err := p.scanAlterClause("LOCK"); err != nil {
+				return err
+			}
+		case IDENT, DEFAULT, CHARACTER, COMMENT, AUTO_INCREMENT:
+			if tok == IDENT && strings.EqualFold(lit, "ALGORITHM") {
+				if err := p.scanAlterClause("ALGORITHM"); err != nil {
+					return err
+				}
+				break
+			}
+			// DISCARD TABLESPACE and IMPORT TABLESPACE leave the schema alone
+			if tok == IDENT && (strings.EqualFold(lit, "DISCARD") || strings.EqualFold(lit, "IMPORT")) {
+				if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != IDENT || !strings.EqualFold(lit1, "TABLESPACE") {
+					return fmt.Errorf("found %q, expected %s TABLESPACE", lit1, strings.ToUpper(lit))
+				}
+				break
+			}
+			p.unscan()
+			if err := p.scanTableOption(table); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("found %q, expected ADD, DROP, MODIFY or CONVERT", lit)
+		}
+		switch tok, lit = p.scanIgnoreWhitespace(); tok {
+		case COMMA:
+		case SEMI_COLON, EOF:
+			return nil
+		default:
+			return fmt.Errorf("found %q, expected , or ;", lit)
+		}
+	}
+}
+
+// scanAlterClause scans [=] value following ALGORITHM or LOCK, which say
+// how the table is altered rather than what it becomes
+func (p *Parser) scanAlterClause(clause string) error {
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok == EQUAL {
+		tok, lit = p.scanIgnoreWhitespace()
+	}
+	if tok != IDENT && tok != DEFAULT {
+		return fmt.Errorf("found %q, expected %s value", lit, clause)
+	}
+	return nil
+}
+
+// scanAlterDrop scans [COLUMN] column, PRIMARY KEY, INDEX|KEY name,
+// FOREIGN KEY name or CHECK name following DROP and removes it from table
+func (p *Parser) scanAlterDrop(table *Table) error {
+	tok, lit := p.scanIgnoreWhitespace()
+	switch tok {
+	case PRIMARY:
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != KEY {
+			return fmt.Errorf("found %q, expected PRIMARY KEY", lit1)
+		}
+		return table.dropIndex("PRIMARY")
+	case KEY, FOREIGN, CHECK:
+		if tok == FOREIGN {
+			if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != KEY {
+				return fmt.Errorf("found %q, expected FOREIGN KEY", lit1)
+			}
+		}
+		tok1, name := p.scanIdent()
+		if tok1 != IDENT {
+			return fmt.Errorf("found %q, expected %s name", name, strings.ToLower(lit))
+		}
+		switch {
+		case tok == KEY:
+			return table.dropIndex(name)
+		case tok == FOREIGN && table.Constraints[name] != nil:
+			table.removeConstraint(name)
+		case tok == CHECK && table.Checks[name] != nil:
+			delete(table.Checks, name)
+		default:
+			return fmt.Errorf("constraint %q not found in table %q", name, table.Name)
+		}
+		return nil
+	case COLUMN:
+	default:
+		p.unscan()
+	}
+	tok, lit = p.scanIdent()
+	if tok != IDENT {
+		return fmt.Errorf("found %q, expected column name", lit)
+	}
+	return table.RemoveColumn(lit)
+}
+
+// scanColumnPosition scans the optional FIRST or AFTER column following
+// the column definition of ADD or MODIFY and moves the column there
+func (p *Parser) scanColumnPosition(table *Table, name string) error {
+	tok, lit := p.scanIgnoreWhitespace()
+	switch {
+	case tok == IDENT && strings.EqualFold(lit, "FIRST"):
+		table.moveColumn(name, "")
+	case tok == IDENT && strings.EqualFold(lit, "AFTER"):
+		tok1, after := p.scanIdent()
+		if tok1 != IDENT {
+			return fmt.Errorf("found %q, expected column name", after)
+		}
+		if table.Columns[after] == nil || after == name {
+			return fmt.Errorf("column %q not found in table %q", after, table.Name)
+		}
+		table.moveColumn(name, after)
+	default:
+		p.unscan()
+	}
+	return nil
+}
+
+// scanAddConstraint scans [CONSTRAINT [symbol]] followed by FOREIGN KEY
+// or CHECK following ADD and adds the constraint to table
+func (p *Parser) scanAddConstraint(table *Table) error {
+	var name string
+	var err error
+	if tok, _ := p.scanIgnoreWhitespace(); tok == CONSTRAINT {
+		p.unscan()
+		if name, err = p.scanConstraintName(); err != nil {
+			return err
+		}
+	} else {
+		p.unscan()
+	}
+	if name != "" && (table.Constraints[name] != nil || table.Checks[name] != nil) {
+		return fmt.Errorf("duplicate constraint name %q in table %q", name, table.Name)
+	}
+	if tok, _ := p.scanIgnoreWhitespace(); tok == CHECK {
+		check, err := p.scanCheck()
+		if err != nil {
+			return err
+		}
+		check.Name = name
+		table.addCheck(check)
+		return nil
+	}
+	p.unscan()
+	cos, err := p.scanConstraint()
+	if err != nil {
+		return err
+	}
+	for _, column := range cos.ForeignKey {
+		if table.Columns[column] == nil {
+			return fmt.Errorf("foreign key column %q not found in table %q", column, table.Name)
+		}
+	}
+	cos.Index = name
+	table.addConstraint(cos)
+	return nil
+}
+
+// scanTableOption scans a table option such as ENGINE=InnoDB and sets it
+// on table, DEFAULT as the value of an unquoted option removes it. A new
+// DEFAULT CHARSET or COLLATE only applies to columns added later, so the
+// existing string columns keep their charset and collation.
+func (p *Parser) scanTableOption(table *Table) error {
+	key, value, err := p.scanKV()
+	if err != nil {
+		return err
+	}
+	if !stringTableOptions[strings.ToUpper(key)] && strings.EqualFold(value, "DEFAULT") {
+		value = ""
+	}
+	upper := strings.ToUpper(key)
+	if upper != "CHARSET" && upper != "COLLATE" {
+		table.setOption(key, value)
+		return nil
+	}
+	before := table.Clone()
+	table.setOption(key, value)
+	if upper == "CHARSET" {
+		table.Charset = value
+	}
+	for name, column := range table.Columns {
+		old := before.Columns[name]
+		charset, collation := old.EffectiveCharset(before), old.EffectiveCollation(before)
+		if normalizeCharset(column.EffectiveCharset(table)) != normalizeCharset(charset) {
+			column.Charset = charset
+		}
+		if collation != "" && !strings.EqualFold(column.EffectiveCollation(table), collation) {
+			column.Collation = collation
+		}
+	}
+	return nil
+}
+
+// scanAddIndex scans the index definition of ADD PRIMARY KEY, ADD UNIQUE,
+// ADD FULLTEXT or ADD INDEX|KEY whose leading token tok was just scanned,
+// and adds the index to table
+func (p *Parser) scanAddIndex(table *Table, tok Token) error {
+	index, err := p.scanIndex(tok)
+	if err != nil {
+		return err
+	}
+	if err = table.checkKeyColumns(index); err != nil {
+		return err
+	}
+	return table.addNewIndex(index)
+}
+
+// scanCreateIndex scans [UNIQUE | FULLTEXT | SPATIAL] INDEX name [USING
+// type] ON table (key_part, ...) [options] following CREATE, whose first
+// token tok was just scanned, and adds the index to its table in schema
+func (p *Parser) scanCreateIndex(schema Schema, tok Token) error {
+	kind := KeyIndex
+	switch tok {
+	case UNIQUE:
+		kind = UniqueIndex
+	case FULLTEXT:
+		kind = FulltextIndex
+	case SPATIAL:
+		kind = SpatialIndex
+	}
+	if kind != KeyIndex {
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != KEY {
+			return fmt.Errorf("found %q, expected INDEX", lit1)
+		}
+	}
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok != IDENT {
+		return fmt.Errorf("found %q, expected index name", lit)
+	}
+	index := &Index{Name: lit, Kind: kind}
+	if tok, _ = p.scanIgnoreWhitespace(); tok == USING {
+		if err := p.scanUsing(index); err != nil {
+			return err
+		}
+	} else {
+		p.unscan()
+	}
+	if tok, lit = p.scanIgnoreWhitespace(); tok != ON {
+		return fmt.Errorf("found %q, expected ON", lit)
+	}
+	_, name, err := p.scanQualifiedIdent()
+	if err != nil {
+		return err
+	}
+	table := schema[name]
+	if table == nil {
+		return fmt.Errorf("table %q not found", name)
+	}
+	if index.Parts, err = p.scanKeyParts(); err != nil {
+		return err
+	}
+	if err = p.scanIndexOptions(index); err != nil {
+		return err
+	}
+	delete(index.Options, "ALGORITHM") // how the index is built, not part of it
+	if err = table.checkKeyColumns(index); err != nil {
+		return err
+	}
+	return table.addNewIndex(index)
+}
+
+// checkKeyColumns checks that the columns of index exist in t
+func (t *Table) checkKeyColumns(index *Index) error {
+	for _, part := range index.Parts {
+		if part.Column != "" && t.Columns[part.Column] == nil {
+			return fmt.Errorf("key column %q not found in table %q", part.Column, t.Name)
+		}
+	}
+	return nil
+}
+
+// addNewIndex adds index after checking that it clashes with no existing
+// key, as ALTER TABLE ADD, CREATE INDEX and inline column keys do
+func (t *Table) addNewIndex(index *Index) error {
+	if index.Kind == PrimaryIndex && t.Indexes["PRIMARY"] != nil {
+		return fmt.Errorf("table %q already has a primary key", t.Name)
+	}
+	if index.Name != "" && t.Indexes[index.Name] != nil {
+		return fmt.Errorf("duplicate key name %q in table %q", index.Name, t.Name)
+	}
+	t.addIndex(index)
+	return nil
+}
+
+// dropIndex removes the index name from t
+func (t *Table) dropIndex(name string) error {
+	index := t.Indexes[name]
+	if index == nil {
+		return fmt.Errorf("index %q not found in table %q", name, t.Name)
+	}
+	index.Parts = nil
+	t.syncIndex(index)
+	return nil
+}
+
+// scanConvertCharset scans TO CHARACTER SET charset [COLLATE collation]
+// following CONVERT and sets the table charset, the collation is dropped
+// unless given since it belongs to the old charset. String columns are
+// converted too, so they lose their own charset and collation.
+func (p *Parser) scanConvertCharset(table *Table) error {
+	tok1, lit1 := p.scanIgnoreWhitespace()
+	tok2, lit2 := p.scanIgnoreWhitespace()
+	if tok1 != TO {
+		return fmt.Errorf("found %q, expected CONVERT TO", lit1)
+	}
+	if tok2 == CHARACTER {
+		if tok3, lit3 := p.scanIgnoreWhitespace(); tok3 != SET {
+			return fmt.Errorf("found %q, expected CHARACTER SET", lit3)
+		}
+	} else if tok2 != IDENT || !strings.EqualFold(lit2, "CHARSET") {
+		return fmt.Errorf("found %q, expected CHARACTER SET", lit2)
+	}
+	charset, err := p.scanName("charset")
+	if err != nil {
+		return err
+	}
+	var collation string
+	if tok, lit := p.scanIgnoreWhitespace(); tok == IDENT && strings.EqualFold(lit, "COLLATE") {
+		if collation, err = p.scanName("collation"); err != nil {
+			return err
+		}
+	} else {
+		p.unscan()
+	}
+	table.setOption("CHARSET", charset)
+	table.setOption("COLLATE", collation)
+	table.Charset = charset
+	for _, column := range table.Columns {
+		if column.Category() == StringType {
+			column.Charset, column.Collation = "", ""
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaApply(t *testing.T) {
+	schema, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration := "-- add email\nALTER TABLE `user` ADD COLUMN `email` varchar(255) NOT NULL DEFAULT '';\n"
+	diff, err := schema.Apply(strings.NewReader(migration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.AddedTables) != 0 || len(diff.DroppedTables) != 0 || len(diff.ChangedTables) != 1 {
+		t.Fatalf("expected only user to change, found %+v", diff)
+	}
+	td := diff.ChangedTables[0]
+	if td.Name != "user" || len(td.AddedColumns) != 1 || len(td.DroppedColumns) != 0 || len(td.ModifiedColumns) != 0 {
+		t.Fatalf("expected one added column in user, found %+v", td)
+	}
+	if sql := td.AddedColumns[0].SQL(); sql != "`email` varchar(255) NOT NULL DEFAULT ''" {
+		t.Errorf("unexpected added column %s", sql)
+	}
+	if schema["user"].Columns["email"] != nil {
+		t.Errorf("expected Apply to leave the schema untouched")
+	}
+}
+
+func TestSchemaApplyStatements(t *testing.T) {
+	schema, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(20),\n  `age` int\n);\nCREATE TABLE `city` (\n  `id` bigint(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration := "ALTER TABLE `user` MODIFY `name` varchar(40), DROP COLUMN `age`;\n" +
+		"DROP TABLE IF EXISTS `city`, `country`;\n" +
+		"CREATE TABLE `order` (\n  `id` bigint(20)\n);\n"
+	to, err := schema.apply(strings.NewReader(migration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to["city"] != nil || to["order"] == nil {
+		t.Errorf("expected city dropped and order created, found %v", tableNames(to))
+	}
+	user := to["user"]
+	if user.Columns["age"] != nil || user.Columns["name"].Size != 40 {
+		t.Errorf("expected age dropped and name widened, found %v", user.columnNames())
+	}
+
+	for _, migration := range []string{
+		"ALTER TABLE `user` ADD `name` int;",
+		"ALTER TABLE `user` MODIFY `email` int;",
+		"ALTER TABLE `missing` DROP `id`;",
+		"DROP TABLE `missing`;",
+	} {
+		if _, err := schema.Apply(strings.NewReader(migration)); err == nil {
+			t.Errorf("expected error applying %q", migration)
+		}
+	}
+}
+
+func TestSchemaApplyDropReferencedTable(t *testing.T) {
+	schema, err := ParseString(fkSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schema.Apply(strings.NewReader("DROP TABLE `city`;")); err == nil || !strings.Contains(err.Error(), "referenced") {
+		t.Errorf("expected dropping a referenced table to fail, found %v", err)
+	}
+	for _, migration := range []string{"DROP TABLE `user`, `city`;", "DROP TABLE `city`, `user`;"} {
+		diff, err := schema.Apply(strings.NewReader(migration))
+		if err != nil {
+			t.Errorf("%s: %v", migration, err)
+			continue
+		}
+		if len(diff.DroppedTables) != 2 || len(diff.ChangedTables) != 0 {
+			t.Errorf("%s: expected user and city dropped and nothing else, found %+v", migration, diff)
+		}
+	}
+}
+
+func TestSchemaApplyAddIndexWithoutKeyword(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `place` (\n  `a` int,\n  `b` int,\n  `bio` text,\n  `geom` point NOT NULL,\n" +
+		"  UNIQUE `ua` (`a`),\n  FULLTEXT (`bio`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	place := schema["place"]
+	if index := place.Indexes["ua"]; index == nil || index.Kind != UniqueIndex {
+		t.Errorf("expected unique key ua, found %+v", index)
+	}
+	if index := place.Indexes["bio"]; index == nil || index.Kind != FulltextIndex {
+		t.Errorf("expected fulltext key bio, found %+v", index)
+	}
+	expected := map[string]string{
+		"ALTER TABLE `place` ADD UNIQUE `ub` (`b`);":       "UNIQUE KEY `ub` (`b`)",
+		"ALTER TABLE `place` ADD UNIQUE (`b`);":            "UNIQUE KEY `b` (`b`)",
+		"ALTER TABLE `place` ADD FULLTEXT `ft` (`bio`);":   "FULLTEXT KEY `ft` (`bio`)",
+		"ALTER TABLE `place` ADD SPATIAL `sp` (`geom`);":   "SPATIAL KEY `sp` (`geom`)",
+		"ALTER TABLE `place` ADD UNIQUE INDEX `ub` (`b`);": "UNIQUE KEY `ub` (`b`)",
+	}
+	for migration, sql := range expected {
+		diff, err := schema.Apply(strings.NewReader(migration))
+		if err != nil {
+			t.Errorf("%s: %v", migration, err)
+			continue
+		}
+		if td := diff.ChangedTables; len(td) != 1 || len(td[0].AddedIndexes) != 1 || td[0].AddedIndexes[0].SQL() != sql {
+			t.Errorf("%s: expected %s added, found %+v", migration, sql, td)
+		}
+	}
+}
+
+func TestSchemaApplyConvertCharset(t *testing.T) {
+	schema, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `name` varchar(20),\n  `code` char(2) CHARACTER SET ascii COLLATE ascii_bin\n) ENGINE=InnoDB DEFAULT CHARSET=latin1 COLLATE=latin1_swedish_ci;")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := schema.Apply(strings.NewReader("ALTER TABLE `user` CONVERT TO CHARACTER SET utf8mb4;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.ChangedTables) != 1 || diff.ChangedTables[0].Charset != "utf8mb4" {
+		t.Fatalf("expected user charset to change to utf8mb4, found %+v", diff.ChangedTables)
+	}
+
+	to, err := schema.apply(strings.NewReader("ALTER TABLE `user` CONVERT TO CHARSET utf8mb4 COLLATE utf8mb4_bin;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := to["user"]
+	if user.Charset != "utf8mb4" || user.Extras["CHARSET"] != "utf8mb4" || user.Extras["COLLATE"] != "utf8mb4_bin" {
+		t.Errorf("expected charset utf8mb4 collate utf8mb4_bin, found %q %v", user.Charset, user.Extras)
+	}
+	if code := user.Columns["code"]; code.Charset != "" || code.Collation != "" {
+		t.Errorf("expected column code to be converted, found %q %q", code.Charset, code.Collation)
+	}
+	if schema["user"].Charset != "latin1" || schema["user"].Columns["code"].Charset != "ascii" {
+		t.Errorf("expected original charsets to be untouched, found %q", schema["user"].Charset)
+	}
+}
+
+func TestSchemaApplyAlgorithmAndLock(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` bigint(20)\n) ENGINE=InnoDB;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range []string{
+		"ALTER TABLE `user` ADD COLUMN `age` int, ALGORITHM=INPLACE;",
+		"ALTER TABLE `user` ADD COLUMN `age` int, LOCK=NONE;",
+		"ALTER TABLE `user` ALGORITHM INSTANT, ADD COLUMN `age` int, LOCK DEFAULT;",
+	} {
+		to, err := schema.apply(strings.NewReader(migration))
+		if err != nil {
+			t.Errorf("%s: %v", migration, err)
+			continue
+		}
+		user := to["user"]
+		if user.Columns["age"] == nil || len(user.Extras) != 1 {
+			t.Errorf("%s: expected age added and ENGINE the only option, found %v", migration, user.Extras)
+		}
+		if stmts := schema.Diff(to).AlterStatements(); len(stmts) != 1 || stmts[0] != "ALTER TABLE `user` ADD COLUMN `age` int AFTER `id`" {
+			t.Errorf("%s: expected only the added column, found %q", migration, stmts)
+		}
+	}
+	if _, err := schema.Apply(strings.NewReader("ALTER TABLE `user` LOCK=;")); err == nil {
+		t.Errorf("expected error for LOCK without a value")
+	}
+}
+
+func TestSchemaApplyTablespace(t *testing.T) {
+	schema, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := schema.Apply(strings.NewReader("ALTER TABLE `user` DISCARD TABLESPACE;\nALTER TABLE `user` IMPORT TABLESPACE;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no changes, found %+v", diff)
+	}
+	if _, err := schema.Apply(strings.NewReader("ALTER TABLE `user` DISCARD PARTITION;")); err == nil {
+		t.Errorf("expected error for DISCARD PARTITION")
+	}
+}
+
+func TestSchemaApplyAddIndex(t *testing.T) {
+	schema, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `email` varchar(255),\n  `city_id` int,\n  `bio` text\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration := "ALTER TABLE `user` ADD PRIMARY KEY (`id`), ADD UNIQUE KEY `uk_email` (`email`);\n" +
+		"ALTER TABLE `user` ADD INDEX `idx_city` (`city_id`, `id`), ADD KEY (`email`(10)), ADD FULLTEXT KEY `ft_bio` (`bio`);\n"
+	diff, err := schema.Apply(strings.NewReader(migration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.ChangedTables) != 1 {
+		t.Fatalf("expected user to change, found %+v", diff)
+	}
+	td := diff.ChangedTables[0]
+	if len(td.DroppedIndexes) != 0 || len(td.AddedColumns) != 0 || len(td.ModifiedColumns) != 0 {
+		t.Errorf("expected only added indexes, found %+v", td)
+	}
+	added := make(map[string]*Index)
+	for _, index := range td.AddedIndexes {
+		added[index.Name] = index
+	}
+	expected := map[string]string{
+		"PRIMARY":  "PRIMARY KEY (`id`)",
+		"uk_email": "UNIQUE KEY `uk_email` (`email`)",
+		"idx_city": "KEY `idx_city` (`city_id`,`id`)",
+		"email":    "KEY `email` (`email`(10))",
+		"ft_bio":   "FULLTEXT KEY `ft_bio` (`bio`)",
+	}
+	if len(added) != len(expected) {
+		t.Errorf("expected %d added indexes, found %d", len(expected), len(added))
+	}
+	for name, sql := range expected {
+		if index := added[name]; index == nil || index.SQL() != sql {
+			t.Errorf("expected added index %s, found %+v", sql, index)
+		}
+	}
+	if len(schema["user"].Indexes) != 0 {
+		t.Errorf("expected the original schema untouched")
+	}
+
+	to, err := schema.apply(strings.NewReader(migration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range []string{
+		"ALTER TABLE `user` ADD PRIMARY KEY (`id`);",
+		"ALTER TABLE `user` ADD KEY `uk_email` (`id`);",
+		"ALTER TABLE `user` ADD INDEX `idx_missing` (`missing`);",
+		"ALTER TABLE `user` ADD COLUMN `z` int PRIMARY KEY;",
+		"ALTER TABLE `user` MODIFY `email` varchar(255) PRIMARY KEY;",
+		"CREATE TABLE `pair` (\n  `a` int PRIMARY KEY,\n  `b` int PRIMARY KEY\n);",
+	} {
+		if _, err := to.Apply(strings.NewReader(migration)); err == nil {
+			t.Errorf("expected error applying %q", migration)
+		}
+	}
+}
+
+func TestSchemaApplyCreateStatements(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `t` (\n  `a` int,\n  `b` int\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := schema.apply(strings.NewReader("CREATE TABLE `u` (`x` int);\nCREATE VIEW `v` AS select 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to["u"] == nil || len(to) != 2 {
+		t.Errorf("expected u created and the view skipped, found %v", tableNames(to))
+	}
+	if diff, err := schema.Apply(strings.NewReader("CREATE VIEW `v` AS select 1;")); err != nil || !diff.Empty() {
+		t.Errorf("expected a lone view to change nothing, found %+v %v", diff, err)
+	}
+
+	migration := "CREATE INDEX `ib` ON `t` (`b`);\nCREATE UNIQUE INDEX `ua` USING BTREE ON `t` (`a`, `b`) ALGORITHM=INPLACE;\nCREATE TABLE `u` (`x` int);"
+	diff, err := schema.Apply(strings.NewReader(migration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.AddedTables) != 1 || diff.AddedTables[0].Name != "u" || len(diff.ChangedTables) != 1 {
+		t.Fatalf("expected u added and t changed, found %+v", diff)
+	}
+	added := diff.ChangedTables[0].AddedIndexes
+	if len(added) != 2 || added[0].SQL() != "UNIQUE KEY `ua` (`a`,`b`) USING BTREE" || added[1].SQL() != "KEY `ib` (`b`)" {
+		t.Errorf("expected ua and ib added, found %v", added)
+	}
+	if len(added) > 0 && len(added[0].Options) != 0 {
+		t.Errorf("expected ALGORITHM not kept as an index option, found %v", added[0].Options)
+	}
+
+	for _, migration := range []string{
+		"CREATE INDEX `ib` ON `missing` (`b`);",
+		"CREATE INDEX `ib` ON `t` (`missing`);",
+		"CREATE INDEX ON `t` (`b`);",
+		"CREATE INDEX `ib` ON `t` (`b`); CREATE INDEX `ib` ON `t` (`a`);",
+	} {
+		if _, err := schema.Apply(strings.NewReader(migration)); err == nil {
+			t.Errorf("expected error applying %q", migration)
+		}
+	}
+}
+
+func TestSchemaApplySkipsOtherStatements(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `t` (\n  `a` int,\n  `b` int,\n  KEY `ia` (`a`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration := "SET FOREIGN_KEY_CHECKS=0;\nINSERT INTO `t` VALUES (1,2);\nDROP VIEW IF EXISTS `v`;\n" +
+		"DROP INDEX `ia` ON `t`;\nALTER TABLE `t` ADD INDEX `ib` (`b`);\nSET FOREIGN_KEY_CHECKS=1;"
+	diff, err := schema.Apply(strings.NewReader(migration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.ChangedTables) != 1 {
+		t.Fatalf("expected t to change, found %+v", diff)
+	}
+	td := diff.ChangedTables[0]
+	if len(td.DroppedIndexes) != 1 || td.DroppedIndexes[0].Name != "ia" || len(td.AddedIndexes) != 1 || td.AddedIndexes[0].Name != "ib" {
+		t.Errorf("expected ia dropped and ib added, found %v %v", td.DroppedIndexes, td.AddedIndexes)
+	}
+	if _, err := schema.Apply(strings.NewReader("DROP INDEX `missing` ON `t`;")); err == nil {
+		t.Errorf("expected error dropping a missing index")
+	}
+}
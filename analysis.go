@@ -0,0 +1,299 @@
+package sqlparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RowSizeLimit is the maximum in-row size in bytes of an InnoDB row with the
+// default 16KB page size, about half a page
+const RowSizeLimit = 8126
+
+// ExceedsRowSizeLimit estimates the in-row bytes of the table and reports
+// whether MySQL would likely reject it with "Row size too large". The
+// estimate uses the maximum size of each column in its own charset, text
+// and blob columns count as an off-page pointer.
+func (t *Table) ExceedsRowSizeLimit() (bool, int) {
+	size := 0
+	for _, column := range t.Columns {
+		size += column.rowSize(normalizeCharset(column.EffectiveCharset(t)))
+	}
+	return size > RowSizeLimit, size
+}
+
+// rowSize returns the maximum bytes the column takes in the row
+func (c *Column) rowSize(charset string) int {
+	switch CanonicalType(c.Type) {
+	case "bit":
+		return (c.EffectiveSize() + 7) / 8
+	case "tinyint":
+		return 1
+	case "smallint":
+		return 2
+	case "int", "float":
+		return 4
+	case "bigint", "double":
+		return 8
+	case "decimal":
+		return decimalSize(c.EffectiveSize(), c.Scale)
+	case "date", "time":
+		return 3 + (c.Size+1)/2
+	case "datetime":
+		return 5 + (c.Size+1)/2
+	case "timestamp":
+		return 4 + (c.Size+1)/2
+	case "char":
+		return c.EffectiveSize() * maxBytesPerChar(charset)
+	case "varchar":
+		n := c.EffectiveSize() * maxBytesPerChar(charset)
+		if n > 255 {
+			return n + 2
+		}
+		return n + 1
+	case "tinytext", "text", "mediumtext", "longtext", "tinyblob", "blob", "mediumblob", "longblob":
+		return 20 // pointer to the off-page value
+	}
+	return 0
+}
+
+// decimalSize returns the bytes of a decimal as MySQL packs it, 4 bytes
+// per 9 digits and fewer for the leftover digits, counted separately for
+// the integer and the fraction part
+func decimalSize(precision, scale int) int {
+	leftover := [9]int{0, 1, 1, 2, 2, 3, 3, 4, 4}
+	part := func(digits int) int {
+		if digits < 0 {
+			return 0
+		}
+		return digits/9*4 + leftover[digits%9]
+	}
+	return part(precision-scale) + part(scale)
+}
+
+// maxBytesPerChar returns the widest character of charset, unknown and
+// empty charsets are assumed to be utf8mb4
+func maxBytesPerChar(charset string) int {
+	switch charset {
+	case "latin1", "ascii", "binary":
+		return 1
+	case "ucs2", "gbk", "big5":
+		return 2
+	case "utf8mb3", "ujis", "sjis":
+		return 3
+	}
+	return 4
+}
+
+// NaturalKey returns the columns of the first unique key, by name, whose
+// columns are all NOT NULL, falling back to the primary key columns. It
+// returns nil when the table has neither.
+func (t *Table) NaturalKey() []string {
+	var primary []string
+	for _, index := range t.sortedIndexes() {
+		switch index.Kind {
+		case PrimaryIndex:
+			primary = t.keyColumns(index)
+		case UniqueIndex:
+			columns := t.keyColumns(index)
+			if columns != nil {
+				return columns
+			}
+		}
+	}
+	return primary
+}
+
+// keyColumns returns the columns of index, nil if a part is an expression
+// or a nullable column
+func (t *Table) keyColumns(index *Index) []string {
+	var columns []string
+	for _, part := range index.Parts {
+		c := t.Columns[part.Column]
+		if part.Expr != "" || c == nil || c.Nullable {
+			return nil
+		}
+		columns = append(columns, part.Column)
+	}
+	return columns
+}
+
+// Risk describes a column whose behaviour differs between MySQL versions
+type Risk struct {
+	Table  string
+	Column string
+	Msg    string
+}
+
+func (r Risk) String() string {
+	return fmt.Sprintf("%s.%s: %s", r.Table, r.Column, r.Msg)
+}
+
+// UpgradeRisks returns the columns relying on timestamp behaviour that
+// changed with explicit_defaults_for_timestamp, the default since MySQL 8:
+// timestamp columns without an explicit DEFAULT, which MySQL 5.7 gave an
+// implicit default, and ON UPDATE CURRENT_TIMESTAMP columns without one.
+// Risks are ordered by table and column.
+func (s Schema) UpgradeRisks() []Risk {
+	var risks []Risk
+	for _, name := range tableNames(s) {
+		table := s[name]
+		for _, column := range table.columnNames() {
+			c := table.Columns[column]
+			switch {
+			case CanonicalType(c.Type) == "timestamp" && c.Default == nil:
+				risks = append(risks, Risk{
+					Table:  name,
+					Column: column,
+					Msg:    "timestamp without DEFAULT relies on implicit defaults, which MySQL 8 no longer applies",
+				})
+			case c.OnUpdate != "" && c.Default == nil:
+				risks = append(risks, Risk{
+					Table:  name,
+					Column: column,
+					Msg:    "ON UPDATE CURRENT_TIMESTAMP without DEFAULT depends on explicit_defaults_for_timestamp",
+				})
+			}
+		}
+	}
+	return risks
+}
+
+// FKEdge is a foreign key from Columns of Table to RefColumns of RefTable
+type FKEdge struct {
+	Table      string
+	Columns    []string
+	Constraint string // the constraint name
+	RefTable   string
+	RefColumns []string
+}
+
+func (e FKEdge) String() string {
+	return fmt.Sprintf("%s.%s -> %s.%s", e.Table, columnList(e.Columns), e.RefTable, columnList(e.RefColumns))
+}
+
+// columnList writes a single column bare and several as (a,b)
+func columnList(columns []string) string {
+	if len(columns) == 1 {
+		return columns[0]
+	}
+	return "(" + strings.Join(columns, ",") + ")"
+}
+
+// UnindexedForeignKeys returns the foreign keys whose columns do not lead
+// any index of its table in order, such foreign keys make the referenced
+// table's deletes and updates scan the child table. Edges are ordered by
+// table and constraint name.
+func (s Schema) UnindexedForeignKeys() []FKEdge {
+	var edges []FKEdge
+	for _, name := range tableNames(s) {
+		table := s[name]
+		var keys []string
+		for key := range table.Constraints {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			cos := table.Constraints[key]
+			if table.indexLeadingWith(cos.ForeignKey) {
+				continue
+			}
+			edges = append(edges, FKEdge{
+				Table:      name,
+				Columns:    cos.ForeignKey,
+				Constraint: cos.Index,
+				RefTable:   cos.TableName,
+				RefColumns: cos.ColumnName,
+			})
+		}
+	}
+	return edges
+}
+
+// DistinctTypes returns every distinct column type of the schema, sorted,
+// in canonical form with its size and scale, e.g. int(11) or
+// decimal(10,2). Enum and set members are left out.
+func (s Schema) DistinctTypes() []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, table := range s {
+		for _, c := range table.Columns {
+			typ := CanonicalType(c.Type)
+			if len(c.members()) == 0 {
+				typ += c.sizeSQL()
+			}
+			if !seen[typ] {
+				seen[typ] = true
+				types = append(types, typ)
+			}
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RedundantIndexes returns pairs of (redundant, covering) index names: a
+// KEY whose parts are a leftmost prefix of another B-tree index, or a
+// UNIQUE, FULLTEXT or SPATIAL key duplicating another one of its kind. A
+// unique key is not made redundant by a non unique one, the primary key
+// never is. Of two identical indexes of the same kind, the one sorting
+// later by name is the redundant one. Pairs are ordered by the redundant
+// index.
+func (t *Table) RedundantIndexes() [][2]string {
+	var pairs [][2]string
+	indexes := t.sortedIndexes()
+	for _, index := range indexes {
+		for _, other := range indexes {
+			if index != other && index.redundantWith(other) {
+				pairs = append(pairs, [2]string{index.Name, other.Name})
+				break
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// indexLeadingWith reports whether some index starts with the whole of
+// columns in the given order
+func (t *Table) indexLeadingWith(columns []string) bool {
+	for _, index := range t.Indexes {
+		if !index.Kind.btree() || len(index.Parts) < len(columns) {
+			continue
+		}
+		leads := true
+		for i, column := range columns {
+			if index.Parts[i].Column != column {
+				leads = false
+				break
+			}
+		}
+		if leads {
+			return true
+		}
+	}
+	return false
+}
+
+// redundantWith reports whether other makes index redundant, see
+// Table.RedundantIndexes
+func (index *Index) redundantWith(other *Index) bool {
+	if len(index.Parts) > len(other.Parts) || (!index.Kind.btree() || !other.Kind.btree()) && index.Kind != other.Kind {
+		return false
+	}
+	for i, part := range index.Parts {
+		if part != other.Parts[i] {
+			return false
+		}
+	}
+	same := len(index.Parts) == len(other.Parts)
+	switch index.Kind {
+	case KeyIndex:
+		return !same || other.Kind != KeyIndex || index.Name > other.Name
+	case UniqueIndex:
+		return same && (other.Kind == PrimaryIndex || other.Kind == UniqueIndex && index.Name > other.Name)
+	case FulltextIndex, SpatialIndex:
+		return same && index.Name > other.Name
+	}
+	return false
+}
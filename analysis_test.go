@@ -0,0 +1,151 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExceedsRowSizeLimit(t *testing.T) {
+	var columns []string
+	for i := 0; i < 10; i++ {
+		columns = append(columns, fmt.Sprintf("`c%d` varchar(255)", i))
+	}
+	wide := "CREATE TABLE `wide` (\n  `id` bigint(20) NOT NULL,\n  " + strings.Join(columns, ",\n  ") + "\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n" +
+		"CREATE TABLE `narrow` (\n  `id` bigint(20) NOT NULL,\n  " + strings.Join(columns, ",\n  ") + "\n) ENGINE=InnoDB DEFAULT CHARSET=latin1;"
+	schema, err := NewParser(strings.NewReader(wide)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exceeds, size := schema["wide"].ExceedsRowSizeLimit(); !exceeds || size != 8+10*(255*4+2) {
+		t.Errorf("expected utf8mb4 table to exceed the limit with %d bytes, found %v %d", 8+10*(255*4+2), exceeds, size)
+	}
+	if exceeds, size := schema["narrow"].ExceedsRowSizeLimit(); exceeds || size != 8+10*(255+1) {
+		t.Errorf("expected latin1 table within the limit with %d bytes, found %v %d", 8+10*(255+1), exceeds, size)
+	}
+}
+
+func TestRowSize(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `t` (\n  `a` decimal(10,2),\n  `b` decimal(20,6),\n  `c` decimal,\n  `d` varchar(255) CHARACTER SET latin1,\n  `e` varchar,\n  `f` bit\n) DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := schema["t"]
+	for name, expected := range map[string]int{"a": 5, "b": 10, "c": 5, "d": 256, "e": 255*4 + 2, "f": 1} {
+		column := table.Columns[name]
+		if size := column.rowSize(normalizeCharset(column.EffectiveCharset(table))); size != expected {
+			t.Errorf("%s: expected %d bytes, found %d", name, expected, size)
+		}
+	}
+	if _, size := table.ExceedsRowSizeLimit(); size != 5+10+5+256+255*4+2+1 {
+		t.Errorf("expected %d bytes, found %d", 5+10+5+256+255*4+2+1, size)
+	}
+}
+
+func TestTableNaturalKey(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `email` varchar(255) NOT NULL,\n  `phone` varchar(20),\n" +
+		"  PRIMARY KEY (`id`),\n  UNIQUE KEY `uk_email` (`email`),\n  UNIQUE KEY `uk_a_phone` (`phone`)\n);\n" +
+		"CREATE TABLE `city` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(20),\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `uk_name` (`name`)\n);\n" +
+		"CREATE TABLE `log` (\n  `msg` varchar(20)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := schema["user"].NaturalKey(); len(key) != 1 || key[0] != "email" {
+		t.Errorf("expected natural key email, found %v", key)
+	}
+	if key := schema["city"].NaturalKey(); len(key) != 1 || key[0] != "id" {
+		t.Errorf("expected natural key to fall back to id, found %v", key)
+	}
+	if key := schema["log"].NaturalKey(); key != nil {
+		t.Errorf("expected no natural key, found %v", key)
+	}
+}
+
+func TestSchemaUpgradeRisks(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `created_at` timestamp NOT NULL,\n" +
+		"  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n  `seen_at` datetime ON UPDATE CURRENT_TIMESTAMP\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := schema["user"].Columns["updated_at"]; c.OnUpdate != "current_timestamp" {
+		t.Errorf("expected ON UPDATE CURRENT_TIMESTAMP, found %q", c.OnUpdate)
+	} else if sql := c.SQL(); sql != "`updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	risks := schema.UpgradeRisks()
+	if len(risks) != 2 {
+		t.Fatalf("expected 2 risks, found %v", risks)
+	}
+	if r := risks[0]; r.Table != "user" || r.Column != "created_at" {
+		t.Errorf("expected risk for user.created_at, found %v", r)
+	}
+	if r := risks[1]; r.Table != "user" || r.Column != "seen_at" {
+		t.Errorf("expected risk for user.seen_at, found %v", r)
+	}
+}
+
+func TestUnindexedForeignKeys(t *testing.T) {
+	schema, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` int,\n  PRIMARY KEY (`id`)\n);\n" +
+		"CREATE TABLE `order` (\n  `id` int,\n  `buyer_id` int,\n  `seller_id` int,\n  `shop_id` int,\n  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_buyer` (`buyer_id`, `id`),\n  KEY `idx_shop_seller` (`shop_id`, `seller_id`),\n" +
+		"  CONSTRAINT `fk_buyer` FOREIGN KEY (`buyer_id`) REFERENCES `user` (`id`),\n" +
+		"  CONSTRAINT `fk_seller` FOREIGN KEY (`seller_id`) REFERENCES `user` (`id`)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges := schema.UnindexedForeignKeys()
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 unindexed foreign key, found %v", edges)
+	}
+	if e := edges[0]; e.Constraint != "fk_seller" || e.String() != "order.seller_id -> user.id" {
+		t.Errorf("expected fk_seller order.seller_id -> user.id, found %+v", e)
+	}
+
+	schema, err = ParseString("CREATE TABLE `shop` (\n  `org_id` int,\n  `id` int,\n  PRIMARY KEY (`org_id`, `id`)\n);\n" +
+		"CREATE TABLE `item` (\n  `id` int,\n  `org_id` int,\n  `shop_id` int,\n  KEY `idx_shop_org` (`shop_id`, `org_id`),\n" +
+		"  CONSTRAINT `fk_shop` FOREIGN KEY (`org_id`, `shop_id`) REFERENCES `shop` (`org_id`, `id`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges = schema.UnindexedForeignKeys()
+	if len(edges) != 1 || edges[0].String() != "item.(org_id,shop_id) -> shop.(org_id,id)" {
+		t.Errorf("expected item.(org_id,shop_id) -> shop.(org_id,id), found %v", edges)
+	}
+	schema["item"].addIndex(&Index{Name: "idx_org_shop", Kind: KeyIndex, Parts: []KeyPart{{Column: "org_id"}, {Column: "shop_id"}, {Column: "id"}}})
+	if edges = schema.UnindexedForeignKeys(); len(edges) != 0 {
+		t.Errorf("expected composite foreign key to be indexed, found %v", edges)
+	}
+}
+
+func TestDistinctTypes(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` integer(11),\n  `name` varchar(20),\n  `status` enum('a','b'),\n  `balance` decimal(10,2),\n  `created` datetime\n);\n" +
+		"CREATE TABLE `city` (\n  `id` int(11),\n  `name` varchar(40),\n  `code` VARCHAR(20),\n  `rank` numeric(10,2)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "datetime,decimal(10,2),enum,int(11),varchar(20),varchar(40)"
+	if types := strings.Join(schema.DistinctTypes(), ","); types != expected {
+		t.Errorf("expected %s, found %s", expected, types)
+	}
+}
+
+func TestRedundantIndexes(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `order` (\n  `id` int NOT NULL,\n  `buyer_id` int,\n  `shop_id` int,\n  `code` varchar(20),\n  `note` text,\n" +
+		"  PRIMARY KEY (`id`),\n  KEY `idx_buyer` (`buyer_id`),\n  KEY `idx_buyer_shop` (`buyer_id`, `shop_id`),\n  KEY `idx_shop` (`shop_id`, `buyer_id`),\n" +
+		"  KEY `idx_id` (`id`),\n  UNIQUE KEY `uk_code` (`code`),\n  UNIQUE KEY `uk_code2` (`code`),\n  KEY `idx_code` (`code`),\n" +
+		"  UNIQUE KEY `uk_code_shop` (`code`, `shop_id`),\n  KEY `idx_note` (`note`(10)),\n  FULLTEXT KEY `ft_note` (`note`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]string{
+		{"idx_buyer", "idx_buyer_shop"},
+		{"idx_code", "uk_code"},
+		{"idx_id", "PRIMARY"},
+		{"uk_code2", "uk_code"},
+	}
+	if pairs := schema["order"].RedundantIndexes(); fmt.Sprint(pairs) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, found %v", expected, pairs)
+	}
+}
@@ -0,0 +1,30 @@
+package sqlparser
+
+// Version is the version of this library
+const Version = "0.2.0"
+
+// Dialect identifies the SQL flavour a dump was produced by
+type Dialect int
+
+const (
+	// MySQL is the default dialect
+	MySQL Dialect = iota
+	MariaDB
+	SQLite
+	PostgreSQL
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case MySQL:
+		return "mysql"
+	case MariaDB:
+		return "mariadb"
+	case SQLite:
+		return "sqlite"
+	case PostgreSQL:
+		return "postgresql"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,20 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDialect(t *testing.T) {
+	p := NewParser(strings.NewReader(""))
+	if p.Dialect != MySQL {
+		t.Errorf("expected default dialect %v, found %v", MySQL, p.Dialect)
+	}
+	p.Dialect = PostgreSQL
+	if p.Dialect.String() != "postgresql" {
+		t.Errorf("expected dialect postgresql, found %v", p.Dialect)
+	}
+	if _, err := p.Parse(); err != nil {
+		t.Error(err)
+	}
+}
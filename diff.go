@@ -0,0 +1,389 @@
+package sqlparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// SchemaDiff holds the changes turning one schema into another
+type SchemaDiff struct {
+	AddedTables   []*Table
+	DroppedTables []*Table
+	ChangedTables []*TableDiff
+}
+
+// TableDiff holds the changes of a table present in both schemas. A
+// changed index, foreign key or check is both dropped and added, the way
+// MySQL has to alter it.
+type TableDiff struct {
+	Name               string
+	Charset            string // the new charset, empty when unchanged
+	Convert            bool   // string columns inheriting the charset change with it, needing CONVERT TO
+	Collation          string // the new table COLLATE, empty when unchanged or dropped
+	AddedColumns       []*Column
+	DroppedColumns     []*Column
+	ModifiedColumns    []*Column         // the new definitions, moved columns included
+	After              map[string]string // the column each added or moved column follows, "" for the first
+	ColumnOrder        []string          // the new column order, added and modified columns are altered in it
+	AddedIndexes       []*Index
+	DroppedIndexes     []*Index
+	AddedConstraints   []*Constraint
+	DroppedConstraints []*Constraint
+	AddedChecks        []*Check
+	DroppedChecks      []*Check
+	Options            map[string]string // changed table options by upper case name, "" when removed
+}
+
+// Empty reports whether the schemas are the same
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.DroppedTables) == 0 && len(d.ChangedTables) == 0
+}
+
+// NameCase emulates MySQL's lower_case_table_names setting when table
+// names are compared
+type NameCase int
+
+const (
+	// CaseSensitive compares table names as given, lower_case_table_names=0
+	CaseSensitive NameCase = iota
+	// LowerCaseStored compares table names in lower case,
+	// lower_case_table_names=1
+	LowerCaseStored
+	// LowerCaseCompared compares table names in lower case but keeps them
+	// as given, lower_case_table_names=2
+	LowerCaseCompared
+)
+
+// equal reports whether the table names a and b are the same under the
+// setting
+func (nc NameCase) equal(a, b string) bool {
+	if nc == CaseSensitive {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
+
+// Lookup returns the table named name, comparing names under the given
+// lower_case_table_names setting, nil if there is none
+func (s Schema) Lookup(name string, nc NameCase) *Table {
+	if table, ok := s[name]; ok {
+		return table
+	}
+	for _, other := range tableNames(s) {
+		if nc.equal(name, other) {
+			return s[other]
+		}
+	}
+	return nil
+}
+
+// Diff returns the changes turning s into to, tables and columns are
+// listed by name
+func (s Schema) Diff(to Schema) *SchemaDiff {
+	return s.DiffCase(to, CaseSensitive)
+}
+
+// DiffCase is like Diff but compares table names under the given
+// lower_case_table_names setting
+func (s Schema) DiffCase(to Schema, nc NameCase) *SchemaDiff {
+	diff := &SchemaDiff{}
+	for _, name := range tableNames(s) {
+		if to.Lookup(name, nc) == nil {
+			diff.DroppedTables = append(diff.DroppedTables, s[name])
+		}
+	}
+	for _, name := range tableNames(to) {
+		from := s.Lookup(name, nc)
+		if from == nil {
+			diff.AddedTables = append(diff.AddedTables, to[name])
+		} else if td := from.diff(to[name]); td != nil {
+			diff.ChangedTables = append(diff.ChangedTables, td)
+		}
+	}
+	return diff
+}
+
+// diff returns the changes turning t into to, nil if there are none
+func (t *Table) diff(to *Table) *TableDiff {
+	td := &TableDiff{Name: to.Name}
+	if t.NormalizedCharset() != to.NormalizedCharset() {
+		td.Charset = to.Charset
+	}
+	for _, name := range t.columnNames() {
+		if to.Columns[name] == nil {
+			td.DroppedColumns = append(td.DroppedColumns, t.Columns[name])
+		}
+	}
+	for _, name := range to.columnNames() {
+		c, column := to.Columns[name], t.Columns[name]
+		if td.Charset != "" && column != nil && c.Category() == StringType && c.Charset == "" && c.Collation == "" &&
+			normalizeCharset(c.EffectiveCharset(to)) != normalizeCharset(column.EffectiveCharset(t)) {
+			td.Convert = true
+		}
+	}
+	stable := stableColumns(t, to)
+	var prev string
+	for _, name := range to.columnNames() {
+		column, ok := t.Columns[name]
+		c := to.Columns[name]
+		moved := ok && !stable[name]
+		switch {
+		case !ok:
+			td.AddedColumns = append(td.AddedColumns, c)
+		case moved, column.SQL() != c.SQL():
+			td.ModifiedColumns = append(td.ModifiedColumns, c)
+		case td.Convert && c.Category() == StringType && (c.Charset != "" || c.Collation != ""):
+			// converting the table to the new charset converts the column too
+			td.ModifiedColumns = append(td.ModifiedColumns, c)
+		}
+		if !ok || moved {
+			if td.After == nil {
+				td.After = make(map[string]string)
+			}
+			td.After[name] = prev
+		}
+		prev = name
+	}
+	if len(td.AddedColumns) > 0 || len(td.ModifiedColumns) > 0 {
+		td.ColumnOrder = to.columnNames()
+	}
+	if t.option("COLLATE") != to.option("COLLATE") {
+		td.Collation = to.option("COLLATE")
+	}
+	td.DroppedIndexes, td.AddedIndexes = diffIndexes(t, to)
+	td.DroppedConstraints, td.AddedConstraints = diffConstraints(t, to)
+	td.DroppedChecks, td.AddedChecks = diffChecks(t, to)
+	td.Options = diffOptions(t, to)
+	if td.Charset == "" && td.Collation == "" && len(td.AddedColumns) == 0 && len(td.DroppedColumns) == 0 &&
+		len(td.ModifiedColumns) == 0 && len(td.AddedIndexes) == 0 && len(td.DroppedIndexes) == 0 &&
+		len(td.AddedConstraints) == 0 && len(td.DroppedConstraints) == 0 && len(td.AddedChecks) == 0 &&
+		len(td.DroppedChecks) == 0 && len(td.Options) == 0 {
+		return nil
+	}
+	return td
+}
+
+// stableColumns returns the largest set of columns of both t and to that
+// keep their relative order, the other columns they share have moved
+func stableColumns(t, to *Table) map[string]bool {
+	position := make(map[string]int)
+	for i, name := range t.columnNames() {
+		position[name] = i
+	}
+	var shared []string
+	for _, name := range to.columnNames() {
+		if _, ok := position[name]; ok {
+			shared = append(shared, name)
+		}
+	}
+	// longest increasing run of old positions, quadratic as tables are small
+	length, prev := make([]int, len(shared)), make([]int, len(shared))
+	best := -1
+	for i := range shared {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if position[shared[j]] < position[shared[i]] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] >= length[best] { // ties favour the run ending last
+			best = i
+		}
+	}
+	stable := make(map[string]bool)
+	for i := best; i >= 0; i = prev[i] {
+		stable[shared[i]] = true
+	}
+	return stable
+}
+
+// diffIndexes returns the indexes of t missing or defined differently in
+// to, and those of to missing or defined differently in t
+func diffIndexes(t, to *Table) (dropped, added []*Index) {
+	for _, index := range t.sortedIndexes() {
+		if other := to.Indexes[index.Name]; other == nil || other.SQL() != index.SQL() {
+			dropped = append(dropped, index)
+		}
+	}
+	for _, index := range to.sortedIndexes() {
+		if other := t.Indexes[index.Name]; other == nil || other.SQL() != index.SQL() {
+			added = append(added, index)
+		}
+	}
+	return dropped, added
+}
+
+// diffConstraints is diffIndexes for foreign keys
+func diffConstraints(t, to *Table) (dropped, added []*Constraint) {
+	for _, name := range t.constraintNames() {
+		if other := to.Constraints[name]; other == nil || other.SQL() != t.Constraints[name].SQL() {
+			dropped = append(dropped, t.Constraints[name])
+		}
+	}
+	for _, name := range to.constraintNames() {
+		if other := t.Constraints[name]; other == nil || other.SQL() != to.Constraints[name].SQL() {
+			added = append(added, to.Constraints[name])
+		}
+	}
+	return dropped, added
+}
+
+// diffChecks is diffIndexes for check constraints
+func diffChecks(t, to *Table) (dropped, added []*Check) {
+	for _, name := range t.checkNames() {
+		if other := to.Checks[name]; other == nil || other.SQL() != t.Checks[name].SQL() {
+			dropped = append(dropped, t.Checks[name])
+		}
+	}
+	for _, name := range to.checkNames() {
+		if other := t.Checks[name]; other == nil || other.SQL() != to.Checks[name].SQL() {
+			added = append(added, to.Checks[name])
+		}
+	}
+	return dropped, added
+}
+
+// diffOptions returns the table options changed from t to to, nil if there
+// are none. CHARSET and COLLATE are diffed on their own and AUTO_INCREMENT,
+// a counter rather than a definition, is left out.
+func diffOptions(t, to *Table) map[string]string {
+	from, into := upperKeys(t.Extras), upperKeys(to.Extras)
+	var options map[string]string
+	set := func(key, value string) {
+		if key == "CHARSET" || key == "COLLATE" || key == "AUTO_INCREMENT" {
+			return
+		}
+		if options == nil {
+			options = make(map[string]string)
+		}
+		options[key] = value
+	}
+	for key := range from {
+		if _, ok := into[key]; !ok {
+			set(key, "")
+		}
+	}
+	for key, value := range into {
+		if from[key] != value {
+			set(key, value)
+		}
+	}
+	return options
+}
+
+func upperKeys(m map[string]string) map[string]string {
+	upper := make(map[string]string, len(m))
+	for k, v := range m {
+		upper[strings.ToUpper(k)] = v
+	}
+	return upper
+}
+
+// specs returns the ALTER TABLE specifications of the changes. The table is
+// converted first so that columns keeping their own charset are modified
+// afterwards, and foreign keys, checks and indexes are dropped before the
+// columns they use and added after them. A charset change no column
+// follows only sets the DEFAULT CHARSET.
+func (td *TableDiff) specs() []string {
+	var specs []string
+	if td.Convert {
+		convert := "CONVERT TO CHARACTER SET " + td.Charset
+		if td.Collation != "" {
+			convert += " COLLATE " + td.Collation
+		}
+		specs = append(specs, convert)
+	}
+	for _, cos := range td.DroppedConstraints {
+		specs = append(specs, "DROP FOREIGN KEY "+quoteIdent(cos.Index))
+	}
+	for _, check := range td.DroppedChecks {
+		specs = append(specs, "DROP CHECK "+quoteIdent(check.Name))
+	}
+	for _, index := range td.DroppedIndexes {
+		if index.Kind == PrimaryIndex {
+			specs = append(specs, "DROP PRIMARY KEY")
+		} else {
+			specs = append(specs, "DROP INDEX "+quoteIdent(index.Name))
+		}
+	}
+	for _, c := range td.DroppedColumns {
+		specs = append(specs, "DROP COLUMN "+quoteIdent(c.Name))
+	}
+	// each column is placed after one already in place when following the
+	// new column order, diffs built without it add columns first
+	columns := make(map[string]string)
+	order := td.ColumnOrder
+	for _, c := range td.AddedColumns {
+		columns[c.Name] = "ADD COLUMN " + c.SQL()
+		if td.ColumnOrder == nil {
+			order = append(order, c.Name)
+		}
+	}
+	for _, c := range td.ModifiedColumns {
+		columns[c.Name] = "MODIFY COLUMN " + c.SQL()
+		if td.ColumnOrder == nil {
+			order = append(order, c.Name)
+		}
+	}
+	for _, name := range order {
+		spec, ok := columns[name]
+		if !ok {
+			continue
+		}
+		if after, ok := td.After[name]; ok && after == "" {
+			spec += " FIRST"
+		} else if ok {
+			spec += " AFTER " + quoteIdent(after)
+		}
+		specs = append(specs, spec)
+	}
+	for _, index := range td.AddedIndexes {
+		specs = append(specs, "ADD "+index.SQL())
+	}
+	for _, cos := range td.AddedConstraints {
+		specs = append(specs, "ADD "+cos.SQL())
+	}
+	for _, check := range td.AddedChecks {
+		specs = append(specs, "ADD "+check.SQL())
+	}
+	options := make(map[string]string, len(td.Options))
+	for key, value := range td.Options {
+		if value == "" && !stringTableOptions[key] {
+			value = "DEFAULT"
+		}
+		options[key] = value
+	}
+	if !td.Convert && td.Charset != "" {
+		options["CHARSET"] = td.Charset
+	}
+	if !td.Convert && td.Collation != "" {
+		options["COLLATE"] = td.Collation
+	}
+	return append(specs, tableOptions(options)...)
+}
+
+func tableNames(s Schema) []string {
+	var names []string
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AlterStatements returns the statements turning the old schema into the
+// new one, without terminating semicolons: CREATE TABLE for added tables,
+// one ALTER TABLE per changed table and DROP TABLE for dropped tables
+func (d *SchemaDiff) AlterStatements() []string {
+	var stmts []string
+	for _, table := range d.AddedTables {
+		stmts = append(stmts, table.SQL())
+	}
+	for _, td := range d.ChangedTables {
+		stmts = append(stmts, "ALTER TABLE "+quoteIdent(td.Name)+" "+strings.Join(td.specs(), ", "))
+	}
+	for _, table := range d.DroppedTables {
+		stmts = append(stmts, "DROP TABLE "+quoteIdent(table.Name))
+	}
+	return stmts
+}
@@ -0,0 +1,256 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	from, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(20),\n  `age` int\n);\nCREATE TABLE `city` (\n  `id` bigint(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(40),\n  `email` varchar(255)\n);\nCREATE TABLE `order` (\n  `id` bigint(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := from.Diff(to)
+	if len(diff.AddedTables) != 1 || diff.AddedTables[0].Name != "order" {
+		t.Errorf("expected added table order, found %v", diff.AddedTables)
+	}
+	if len(diff.DroppedTables) != 1 || diff.DroppedTables[0].Name != "city" {
+		t.Errorf("expected dropped table city, found %v", diff.DroppedTables)
+	}
+	if len(diff.ChangedTables) != 1 {
+		t.Fatalf("expected 1 changed table, found %d", len(diff.ChangedTables))
+	}
+	td := diff.ChangedTables[0]
+	if len(td.AddedColumns) != 1 || td.AddedColumns[0].Name != "email" {
+		t.Errorf("expected added column email, found %v", td.AddedColumns)
+	}
+	if len(td.DroppedColumns) != 1 || td.DroppedColumns[0].Name != "age" {
+		t.Errorf("expected dropped column age, found %v", td.DroppedColumns)
+	}
+	if len(td.ModifiedColumns) != 1 || td.ModifiedColumns[0].Size != 40 {
+		t.Errorf("expected modified column name, found %v", td.ModifiedColumns)
+	}
+	if !from.Diff(from.Clone()).Empty() {
+		t.Errorf("expected no changes against a clone")
+	}
+}
+
+func TestSchemaDiffAlterStatements(t *testing.T) {
+	from, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `name` varchar(20),\n  `email` varchar(255) NOT NULL DEFAULT ''\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := from.Diff(to).AlterStatements()
+	expected := "ALTER TABLE `user` ADD COLUMN `email` varchar(255) NOT NULL DEFAULT '' AFTER `name`"
+	if len(stmts) != 1 || stmts[0] != expected {
+		t.Fatalf("expected %q, found %q", expected, stmts)
+	}
+	diff, err := from.Apply(strings.NewReader(stmts[0] + ";"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.ChangedTables) != 1 || len(diff.ChangedTables[0].AddedColumns) != 1 {
+		t.Errorf("expected applying the statements to add the column, found %+v", diff)
+	}
+}
+
+func TestSchemaDiffAlterStatementsRoundTrip(t *testing.T) {
+	from, err := ParseString("CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `name` varchar(20),\n  `note` varchar(20) CHARACTER SET latin1,\n  `title` varchar(20),\n  `city_id` int,\n  `age` int,\n" +
+		"  PRIMARY KEY (`id`),\n  KEY `idx_age` (`age`),\n  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`),\n  CONSTRAINT `chk_age` CHECK (age > 0)\n) ENGINE=InnoDB ROW_FORMAT=DYNAMIC DEFAULT CHARSET=latin1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseString("CREATE TABLE `user` (\n  `uuid` char(36),\n  `id` int NOT NULL,\n  `name` varchar(20) CHARACTER SET latin1,\n  `email` varchar(255),\n  `note` varchar(20) CHARACTER SET latin1,\n  `title` varchar(20),\n  `city_id` int,\n" +
+		"  PRIMARY KEY (`id`, `uuid`),\n  UNIQUE KEY `uk_email` (`email`),\n  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`) ON DELETE CASCADE,\n  CONSTRAINT `chk_id` CHECK (id > 0)\n) ENGINE=MyISAM DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := from.Diff(to).AlterStatements()
+	if len(stmts) != 1 || !strings.HasPrefix(stmts[0], "ALTER TABLE `user` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_bin, DROP FOREIGN KEY `fk_city`, ") {
+		t.Fatalf("expected CONVERT then DROP FOREIGN KEY first, found %q", stmts)
+	}
+	for _, spec := range []string{"ADD COLUMN `uuid` char(36) FIRST", "ADD COLUMN `email` varchar(255) AFTER `name`", "DROP PRIMARY KEY", "DROP INDEX `idx_age`",
+		"DROP CHECK `chk_age`", "ADD CONSTRAINT `chk_id` CHECK (id > 0)", "ENGINE=MyISAM", "ROW_FORMAT=DEFAULT"} {
+		if !strings.Contains(stmts[0], spec) {
+			t.Errorf("expected %q in %q", spec, stmts[0])
+		}
+	}
+	applied, err := from.apply(strings.NewReader(stmts[0] + ";"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := applied.Diff(to); !diff.Empty() {
+		t.Errorf("expected the statements to reach the new schema, found %+v", diff.ChangedTables[0])
+	}
+	if s, expected := applied["user"].SQL(), to["user"].SQL(); s != expected {
+		t.Errorf("expected %q, found %q", expected, s)
+	}
+}
+
+func TestSchemaDiffMovedColumns(t *testing.T) {
+	from, err := ParseString("CREATE TABLE `t` (\n  `id` int,\n  `a` int,\n  `b` int\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := from.apply(strings.NewReader("ALTER TABLE `t` MODIFY `b` int AFTER `id`;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := from.Diff(to).AlterStatements()
+	if len(stmts) != 1 || stmts[0] != "ALTER TABLE `t` MODIFY COLUMN `b` int AFTER `id`" {
+		t.Errorf("expected b moved after id, found %q", stmts)
+	}
+
+	// every order of a, b, c and d with a new column n somewhere
+	var orders [][]string
+	var permute func(prefix, rest []string)
+	permute = func(prefix, rest []string) {
+		if len(rest) == 0 {
+			orders = append(orders, prefix)
+		}
+		for i := range rest {
+			next := append(append([]string(nil), rest[:i]...), rest[i+1:]...)
+			permute(append(append([]string(nil), prefix...), rest[i]), next)
+		}
+	}
+	permute(nil, []string{"a", "b", "c", "d"})
+	from, err = ParseString("CREATE TABLE `t` (\n  `a` int,\n  `b` int,\n  `c` int,\n  `d` int\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, order := range orders {
+		at := i % 5
+		order = append(append(append([]string(nil), order[:at]...), "n"), order[at:]...)
+		var columns []string
+		for _, name := range order {
+			columns = append(columns, "`"+name+"` int")
+		}
+		to, err := ParseString("CREATE TABLE `t` (\n  " + strings.Join(columns, ",\n  ") + "\n);")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts := from.Diff(to).AlterStatements()
+		applied, err := from.apply(strings.NewReader(strings.Join(stmts, ";\n") + ";"))
+		if err != nil {
+			t.Errorf("%v: %v", order, err)
+			continue
+		}
+		if found := strings.Join(applied["t"].ColumnOrder, ","); found != strings.Join(order, ",") {
+			t.Errorf("%v: %q reordered the columns to %s", order, stmts, found)
+		}
+	}
+}
+
+func TestSchemaDiffDefaultCharset(t *testing.T) {
+	from, err := ParseString("CREATE TABLE `user` (\n  `name` varchar(20),\n  `code` char(2) CHARACTER SET ascii\n) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := from.apply(strings.NewReader("ALTER TABLE `user` DEFAULT CHARSET=latin1;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := to["user"].Columns["name"]; name.Charset != "utf8mb4" || name.Collation != "utf8mb4_bin" {
+		t.Errorf("expected name to keep utf8mb4_bin, found %q %q", name.Charset, name.Collation)
+	}
+	diff := from.Diff(to)
+	if len(diff.ChangedTables) != 1 || diff.ChangedTables[0].Convert {
+		t.Fatalf("expected a default charset change without conversion, found %+v", diff.ChangedTables)
+	}
+	stmts := diff.AlterStatements()
+	if len(stmts) != 1 || strings.Contains(stmts[0], "CONVERT") || !strings.Contains(stmts[0], "DEFAULT CHARSET=latin1") {
+		t.Fatalf("expected DEFAULT CHARSET=latin1 without CONVERT, found %q", stmts)
+	}
+	applied, err := from.apply(strings.NewReader(stmts[0] + ";"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := applied.Diff(to); !diff.Empty() {
+		t.Errorf("expected the statements to reach the new schema, found %+v", diff.ChangedTables[0])
+	}
+	if same, err := from.apply(strings.NewReader("ALTER TABLE `user` DEFAULT CHARSET=utf8mb4;")); err != nil || !from.Diff(same).Empty() {
+		t.Errorf("expected restating the default charset to change nothing, found %v", err)
+	}
+}
+
+func TestSchemaDiffNameCase(t *testing.T) {
+	from, err := NewParser(strings.NewReader("CREATE TABLE `User` (\n  `id` bigint(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20)\n);")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := from.DiffCase(to, CaseSensitive)
+	if len(diff.AddedTables) != 1 || len(diff.DroppedTables) != 1 {
+		t.Errorf("expected user added and User dropped, found %+v", diff)
+	}
+	if diff := from.Diff(to); diff.Empty() {
+		t.Errorf("expected Diff to be case sensitive")
+	}
+	for _, nc := range []NameCase{LowerCaseStored, LowerCaseCompared} {
+		if diff := from.DiffCase(to, nc); !diff.Empty() {
+			t.Errorf("%d: expected no changes, found %+v", nc, diff)
+		}
+		if table := from.Lookup("USER", nc); table == nil || table.Name != "User" {
+			t.Errorf("%d: expected lookup to find User, found %v", nc, table)
+		}
+	}
+	if table := from.Lookup("user", CaseSensitive); table != nil {
+		t.Errorf("expected no case sensitive match, found %v", table.Name)
+	}
+}
+
+func TestSchemaDiffKeysAndOptions(t *testing.T) {
+	from, err := ParseString("CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `city_id` int,\n  `age` int,\n  PRIMARY KEY (`id`),\n  KEY `idx_age` (`age`),\n  KEY `idx_city` (`city_id`),\n" +
+		"  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`),\n  CONSTRAINT `chk_age` CHECK (age > 0)\n) ENGINE=InnoDB AUTO_INCREMENT=5 ROW_FORMAT=DYNAMIC;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseString("CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `city_id` int,\n  `age` int,\n  PRIMARY KEY (`id`),\n  KEY `idx_age` (`age`, `id`),\n  UNIQUE KEY `uk_city` (`city_id`),\n" +
+		"  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`) ON DELETE CASCADE,\n  CONSTRAINT `chk_age` CHECK (age > 0)\n) engine=MyISAM AUTO_INCREMENT=9 COLLATE=utf8mb4_bin;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := from.Diff(to)
+	if len(diff.ChangedTables) != 1 {
+		t.Fatalf("expected user to change, found %+v", diff)
+	}
+	td := diff.ChangedTables[0]
+	names := func(indexes []*Index) string {
+		var s []string
+		for _, index := range indexes {
+			s = append(s, index.Name)
+		}
+		return strings.Join(s, ",")
+	}
+	if s := names(td.DroppedIndexes); s != "idx_age,idx_city" {
+		t.Errorf("expected dropped indexes idx_age,idx_city, found %s", s)
+	}
+	if s := names(td.AddedIndexes); s != "uk_city,idx_age" {
+		t.Errorf("expected added indexes uk_city,idx_age, found %s", s)
+	}
+	if len(td.DroppedConstraints) != 1 || len(td.AddedConstraints) != 1 || td.AddedConstraints[0].OnDelete != "CASCADE" {
+		t.Errorf("expected fk_city redefined, found %v %v", td.DroppedConstraints, td.AddedConstraints)
+	}
+	if len(td.DroppedChecks) != 0 || len(td.AddedChecks) != 0 {
+		t.Errorf("expected unchanged checks, found %v %v", td.DroppedChecks, td.AddedChecks)
+	}
+	if td.Collation != "utf8mb4_bin" {
+		t.Errorf("expected collation utf8mb4_bin, found %q", td.Collation)
+	}
+	expected := map[string]string{"ENGINE": "MyISAM", "ROW_FORMAT": ""}
+	if fmt.Sprint(td.Options) != fmt.Sprint(expected) {
+		t.Errorf("expected options %v, found %v", expected, td.Options)
+	}
+}
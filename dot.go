@@ -0,0 +1,45 @@
+package sqlparser
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+)
+
+// ToDOT renders the schema as a Graphviz digraph, tables are nodes listing
+// their columns and foreign keys are edges from the referencing column to
+// the referenced one
+func (s Schema) ToDOT() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph schema {\n  node [shape=plaintext];\n")
+	for _, name := range tableNames(s) {
+		table := s[name]
+		fmt.Fprintf(&buf, "  %s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", strconv.Quote(name))
+		fmt.Fprintf(&buf, "<tr><td><b>%s</b></td></tr>", html.EscapeString(name))
+		for _, column := range table.columnNames() {
+			c := table.Columns[column]
+			fmt.Fprintf(&buf, "<tr><td port=%s align=\"left\">%s %s</td></tr>",
+				strconv.Quote(column), html.EscapeString(column), html.EscapeString(c.Type))
+		}
+		buf.WriteString("</table>>];\n")
+	}
+	for _, name := range tableNames(s) {
+		table := s[name]
+		var keys []string
+		for key := range table.Constraints {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			cos := table.Constraints[key]
+			for i, column := range cos.ForeignKey {
+				fmt.Fprintf(&buf, "  %s:%s -> %s:%s;\n", strconv.Quote(name), strconv.Quote(column),
+					strconv.Quote(cos.TableName), strconv.Quote(cos.ColumnName[i]))
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,29 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaToDOT(t *testing.T) {
+	schema, err := NewParser(strings.NewReader(fkSchema)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := schema.ToDOT()
+	if !strings.HasPrefix(dot, "digraph schema {") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("expected a digraph, found:\n%s", dot)
+	}
+	for _, expected := range []string{
+		"\"country\" [label=<",
+		"\"city\" [label=<",
+		"\"user\" [label=<",
+		"<tr><td port=\"city_id\" align=\"left\">city_id bigint</td></tr>",
+		"\"user\":\"city_id\" -> \"city\":\"id\";",
+		"\"user\":\"country_id\" -> \"country\":\"id\";",
+	} {
+		if !strings.Contains(dot, expected) {
+			t.Errorf("expected DOT to contain %s, found:\n%s", expected, dot)
+		}
+	}
+}
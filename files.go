@@ -0,0 +1,100 @@
+package sqlparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// ParseFiles parses the dump files concurrently, at most GOMAXPROCS at a
+// time, and returns the schemas by path. Files failing to parse are left
+// out and their errors joined in the returned error.
+func ParseFiles(paths []string) (map[string]Schema, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		errs    = make([]error, len(paths))
+		schemas = make(map[string]Schema, len(paths))
+		sem     = make(chan struct{}, runtime.GOMAXPROCS(0))
+	)
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			schema, err := ParseFile(path)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			mu.Lock()
+			schemas[path] = schema
+			mu.Unlock()
+		}(i, path)
+	}
+	wg.Wait()
+	return schemas, errors.Join(errs...)
+}
+
+// ParseFile parses the dump file at path, errors are prefixed with the path
+func ParseFile(path string) (Schema, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer file.Close()
+	schema, err := NewParser(file).Parse()
+	if err != nil {
+		return schema, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// dumpPeekSize is the number of bytes IsLikelyDump looks at
+const dumpPeekSize = 4096
+
+// dump headers written by mysqldump and mariadb-dump
+var dumpHeaders = []string{"-- MySQL dump", "-- MariaDB dump"}
+
+// IsLikelyDump reports whether r looks like a MySQL dump: it starts with a
+// mysqldump header or one of its first statements is CREATE TABLE or DROP
+// TABLE. It reads up to 4KB of r, a reader that is also an io.Seeker is
+// rewound to where it was, any other reader is consumed.
+func IsLikelyDump(r io.Reader) bool {
+	if seeker, ok := r.(io.Seeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			defer seeker.Seek(pos, io.SeekStart)
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(r, dumpPeekSize))
+	if err != nil {
+		return false
+	}
+	head := bytes.TrimSpace(data)
+	for _, header := range dumpHeaders {
+		if bytes.HasPrefix(head, []byte(header)) {
+			return true
+		}
+	}
+	s := NewScanner(bytes.NewReader(data))
+	prev, last := SEMI_COLON, SEMI_COLON // the last two significant tokens
+	for {
+		tok, _ := s.Scan()
+		switch tok {
+		case EOF, ILLEGAL:
+			return false
+		case WS, ANNOTATION:
+			continue
+		case TABLE:
+			if prev == SEMI_COLON && (last == CREATE || last == DROP) {
+				return true
+			}
+		}
+		prev, last = last, tok
+	}
+}
@@ -0,0 +1,83 @@
+package sqlparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("dump%d.sql", i))
+		sqlStmt := fmt.Sprintf("CREATE TABLE `t%d` (\n  `id` bigint(20)\n);", i)
+		if err := os.WriteFile(path, []byte(sqlStmt), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	schemas, err := ParseFiles(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != len(paths) {
+		t.Fatalf("expected %d schemas, found %d", len(paths), len(schemas))
+	}
+	for i, path := range paths {
+		if schemas[path][fmt.Sprintf("t%d", i)] == nil {
+			t.Errorf("expected table t%d in %s", i, path)
+		}
+	}
+
+	missing := filepath.Join(dir, "missing.sql")
+	schemas, err = ParseFiles(append(paths, missing))
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected error naming %s, found %v", missing, err)
+	}
+	if len(schemas) != len(paths) {
+		t.Errorf("expected the other %d files parsed, found %d", len(paths), len(schemas))
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	schema, err := ParseFile("table_schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) == 0 {
+		t.Errorf("expected tables in table_schema.sql")
+	}
+	missing := filepath.Join(t.TempDir(), "missing.sql")
+	if _, err := ParseFile(missing); err == nil || !strings.HasPrefix(err.Error(), missing+": ") || !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("expected not exist error prefixed with %s, found %v", missing, err)
+	}
+}
+
+func TestIsLikelyDump(t *testing.T) {
+	cases := map[string]bool{
+		"-- MySQL dump 10.13  Distrib 8.0.32\n--\n-- Host: localhost":    true,
+		"/* header */\nSET NAMES utf8mb4;\nDROP TABLE IF EXISTS `user`;": true,
+		"CREATE TABLE `user` (`id` int);":                                true,
+		"Dear user, please create table reservations for tonight.":       false,
+		"package main\n\nfunc main() {}\n":                               false,
+		"":                                                               false,
+	}
+	for input, expected := range cases {
+		if found := IsLikelyDump(strings.NewReader(input)); found != expected {
+			t.Errorf("%q: expected %v, found %v", input, expected, found)
+		}
+	}
+
+	r := strings.NewReader("CREATE TABLE `user` (`id` int);")
+	if !IsLikelyDump(r) {
+		t.Fatal("expected a dump")
+	}
+	schema, err := NewParser(r).Parse()
+	if err != nil || schema["user"] == nil {
+		t.Errorf("expected the seeker rewound and table user parsed, found %v %v", schema, err)
+	}
+}
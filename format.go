@@ -0,0 +1,431 @@
+package sqlparser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonicalize parses the dump and writes it back with tables sorted by
+// name and every definition in canonical form, so that two dumps of the
+// same schema compare equal
+func Canonicalize(r io.Reader) (string, error) {
+	schema, err := NewParser(r).Parse()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for i, name := range tableNames(schema) {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(schema[name].SQL() + ";\n")
+	}
+	return buf.String(), nil
+}
+
+// SQL returns the CREATE TABLE statement of the table, without the
+// terminating semicolon
+func (t *Table) SQL() string {
+	return t.SQLQuoted(Backticks)
+}
+
+// SQLQuoted is like SQL but quotes identifiers with q, for databases other
+// than MySQL. Expressions are written as parsed and keep their quoting.
+func (t *Table) SQLQuoted(q IdentQuote) string {
+	var defs []string
+	inline := make(map[string]string) // column -> inline key attribute
+	for _, index := range t.sortedIndexes() {
+		if !index.Inline {
+			continue
+		}
+		if index.Kind == PrimaryIndex {
+			inline[index.Parts[0].Column] = "PRIMARY KEY"
+		} else {
+			inline[index.Parts[0].Column] = "UNIQUE KEY"
+		}
+	}
+	for _, name := range t.columnNames() {
+		defs = append(defs, columnSQL(t.Columns[name], inline[name], q))
+	}
+	for _, index := range t.orderedIndexes() {
+		if !index.Inline {
+			defs = append(defs, index.sql(q))
+		}
+	}
+	for _, name := range t.constraintNames() {
+		defs = append(defs, t.Constraints[name].sql(q))
+	}
+	for _, name := range t.checkNames() {
+		defs = append(defs, t.Checks[name].sql(q))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("CREATE TABLE ")
+	if t.Database != "" {
+		buf.WriteString(q.Quote(t.Database) + ".")
+	}
+	buf.WriteString(q.Quote(t.Name))
+	buf.WriteString(" (\n  ")
+	buf.WriteString(strings.Join(defs, ",\n  "))
+	buf.WriteString("\n)")
+	if options := tableOptionsSQL(t.Extras); options != "" {
+		buf.WriteString(" " + options)
+	}
+	return buf.String()
+}
+
+// sizeSQL returns the (size) or (size,scale) following the type, empty
+// when the column has neither
+func (c *Column) sizeSQL() string {
+	if c.Scale > 0 {
+		return fmt.Sprintf("(%d,%d)", c.Size, c.Scale)
+	} else if c.HasSize || c.Size > 0 {
+		return fmt.Sprintf("(%d)", c.Size)
+	}
+	return ""
+}
+
+// SQL returns the column definition as used in CREATE TABLE
+func (c *Column) SQL() string {
+	return columnSQL(c, "", Backticks)
+}
+
+func columnSQL(c *Column, key string, q IdentQuote) string {
+	var buf bytes.Buffer
+	buf.WriteString(q.Quote(c.Name) + " " + c.Type)
+	if members := c.members(); len(members) > 0 {
+		var values []string
+		for _, v := range members {
+			values = append(values, quoteString(v))
+		}
+		buf.WriteString("(" + strings.Join(values, ",") + ")")
+	} else {
+		buf.WriteString(c.sizeSQL())
+	}
+	if c.Unsigned {
+		buf.WriteString(" unsigned")
+	}
+	if c.Zerofill {
+		buf.WriteString(" zerofill")
+	}
+	if c.Charset != "" {
+		buf.WriteString(" CHARACTER SET " + c.Charset)
+	}
+	if c.Collation != "" {
+		buf.WriteString(" COLLATE " + c.Collation)
+	}
+	if c.SRID != 0 {
+		fmt.Fprintf(&buf, " SRID %d", c.SRID)
+	}
+	if c.Generated != "" {
+		buf.WriteString(" GENERATED ALWAYS AS (" + string(c.Generated) + ")")
+		if c.Stored {
+			buf.WriteString(" STORED")
+		} else {
+			buf.WriteString(" VIRTUAL")
+		}
+	}
+	if !c.Nullable {
+		buf.WriteString(" NOT NULL")
+	}
+	if c.Default != nil {
+		buf.WriteString(" DEFAULT " + formatDefault(c.Default))
+	}
+	if c.OnUpdate != "" {
+		buf.WriteString(" ON UPDATE " + strings.ToUpper(c.OnUpdate))
+	}
+	if c.AutoIncr {
+		buf.WriteString(" AUTO_INCREMENT")
+	}
+	if key != "" {
+		buf.WriteString(" " + key)
+	}
+	if c.Comment != "" {
+		buf.WriteString(" COMMENT " + quoteString(c.Comment))
+	}
+	return buf.String()
+}
+
+// members returns the members of an enum or set column
+func (c *Column) members() []string {
+	if c.EnumValues != nil {
+		return c.EnumValues
+	}
+	return c.SetValues
+}
+
+func formatDefault(v interface{}) string {
+	switch v {
+	case "null":
+		return "NULL"
+	case "current_timestamp":
+		return "CURRENT_TIMESTAMP"
+	}
+	if s, ok := v.(string); ok && strings.HasPrefix(s, "current_timestamp(") {
+		return strings.ToUpper(s)
+	}
+	switch v := v.(type) {
+	case Expr:
+		return "(" + string(v) + ")"
+	case Number:
+		return string(v)
+	case string:
+		return quoteString(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// SQL returns the index definition as used in CREATE TABLE
+func (index *Index) SQL() string {
+	return index.sql(Backticks)
+}
+
+func (index *Index) sql(q IdentQuote) string {
+	var buf bytes.Buffer
+	switch index.Kind {
+	case PrimaryIndex:
+		buf.WriteString("PRIMARY KEY")
+	case UniqueIndex:
+		buf.WriteString("UNIQUE KEY " + q.Quote(index.Name))
+	case FulltextIndex:
+		buf.WriteString("FULLTEXT KEY " + q.Quote(index.Name))
+	case SpatialIndex:
+		buf.WriteString("SPATIAL KEY " + q.Quote(index.Name))
+	default:
+		buf.WriteString("KEY " + q.Quote(index.Name))
+	}
+	var parts []string
+	for _, part := range index.Parts {
+		switch {
+		case part.Expr != "":
+			parts = append(parts, "("+part.Expr+")")
+		case part.Length > 0:
+			parts = append(parts, fmt.Sprintf("%s(%d)", q.Quote(part.Column), part.Length))
+		default:
+			parts = append(parts, q.Quote(part.Column))
+		}
+	}
+	buf.WriteString(" (" + strings.Join(parts, ",") + ")")
+	if index.Using != "" {
+		buf.WriteString(" USING " + index.Using)
+	}
+	for _, key := range sortedKeys(index.Options) {
+		value := index.Options[key]
+		switch {
+		case key == "COMMENT":
+			buf.WriteString(" COMMENT " + quoteString(value))
+		case key == "WITH PARSER":
+			buf.WriteString(" WITH PARSER " + value)
+		case value == "":
+			buf.WriteString(" " + key)
+		default:
+			buf.WriteString(" " + key + "=" + value)
+		}
+	}
+	return buf.String()
+}
+
+// SQL returns the foreign key definition as used in CREATE TABLE
+func (c *Constraint) SQL() string {
+	return c.sql(Backticks)
+}
+
+func (c *Constraint) sql(q IdentQuote) string {
+	var buf bytes.Buffer
+	if c.Index != "" {
+		buf.WriteString("CONSTRAINT " + q.Quote(c.Index) + " ")
+	}
+	fmt.Fprintf(&buf, "FOREIGN KEY (%s) REFERENCES ", q.quoteList(c.ForeignKey))
+	if c.ReferencedDatabase != "" {
+		buf.WriteString(q.Quote(c.ReferencedDatabase) + ".")
+	}
+	fmt.Fprintf(&buf, "%s (%s)", q.Quote(c.TableName), q.quoteList(c.ColumnName))
+	if c.OnDelete != "" {
+		buf.WriteString(" ON DELETE " + c.OnDelete)
+	}
+	if c.OnUpdate != "" {
+		buf.WriteString(" ON UPDATE " + c.OnUpdate)
+	}
+	return buf.String()
+}
+
+// SQL returns the check constraint definition as used in CREATE TABLE
+func (c *Check) SQL() string {
+	return c.sql(Backticks)
+}
+
+func (c *Check) sql(q IdentQuote) string {
+	s := "CONSTRAINT " + q.Quote(c.Name) + " CHECK (" + c.Expr + ")"
+	if !c.Enforced {
+		s += " NOT ENFORCED"
+	}
+	return s
+}
+
+// table options always written first, in this order
+var leadingTableOptions = []string{"ENGINE", "AUTO_INCREMENT", "CHARSET", "COLLATE"}
+
+// table options taking a string value
+var stringTableOptions = map[string]bool{
+	"COMMENT":     true,
+	"COMPRESSION": true,
+	"CONNECTION":  true,
+	"ENCRYPTION":  true,
+	"PASSWORD":    true,
+}
+
+func tableOptionsSQL(extras map[string]string) string {
+	return strings.Join(tableOptions(extras), " ")
+}
+
+// tableOptions returns the table options as key=value in dump order
+func tableOptions(extras map[string]string) []string {
+	keys := sortedKeys(extras)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return optionRank(keys[i]) < optionRank(keys[j])
+	})
+	var options []string
+	for _, key := range keys {
+		value := extras[key]
+		upper := strings.ToUpper(key)
+		if stringTableOptions[upper] {
+			value = quoteString(value)
+		}
+		if upper == "CHARSET" || upper == "COLLATE" {
+			key = "DEFAULT " + key
+		}
+		options = append(options, key+"="+value)
+	}
+	return options
+}
+
+func optionRank(key string) int {
+	for i, k := range leadingTableOptions {
+		if strings.EqualFold(k, key) {
+			return i
+		}
+	}
+	return len(leadingTableOptions)
+}
+
+// sortedIndexes returns the primary key first, then unique, plain,
+// fulltext and spatial keys each sorted by name
+func (t *Table) sortedIndexes() []*Index {
+	var indexes []*Index
+	for _, index := range t.Indexes {
+		indexes = append(indexes, index)
+	}
+	rank := map[IndexKind]int{PrimaryIndex: 0, UniqueIndex: 1, KeyIndex: 2, FulltextIndex: 3, SpatialIndex: 4}
+	sort.Slice(indexes, func(i, j int) bool {
+		if ri, rj := rank[indexes[i].Kind], rank[indexes[j].Kind]; ri != rj {
+			return ri < rj
+		}
+		return indexes[i].Name < indexes[j].Name
+	})
+	return indexes
+}
+
+// orderedIndexes returns the indexes in declaration order with the primary
+// key first, as MySQL shows them, tables built without a complete
+// IndexOrder have them in sortedIndexes order
+func (t *Table) orderedIndexes() []*Index {
+	if len(t.IndexOrder) != len(t.Indexes) {
+		return t.sortedIndexes()
+	}
+	var indexes []*Index
+	if primary := t.Indexes["PRIMARY"]; primary != nil {
+		indexes = append(indexes, primary)
+	}
+	for _, name := range t.IndexOrder {
+		if index := t.Indexes[name]; index != nil && index.Kind != PrimaryIndex {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// columnNames returns the column names in declaration order, tables built
+// without ColumnOrder have their columns sorted by name
+func (t *Table) columnNames() []string {
+	if len(t.ColumnOrder) == len(t.Columns) {
+		return append([]string(nil), t.ColumnOrder...)
+	}
+	var names []string
+	for name := range t.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// constraintNames returns the foreign key names in declaration order,
+// tables built without a complete ConstraintOrder have them sorted
+func (t *Table) constraintNames() []string {
+	if len(t.ConstraintOrder) == len(t.Constraints) {
+		return append([]string(nil), t.ConstraintOrder...)
+	}
+	var names []string
+	for name := range t.Constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkNames returns the check constraint names sorted
+func (t *Table) checkNames() []string {
+	var names []string
+	for name := range t.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IdentQuote selects how formatted DDL quotes identifiers
+type IdentQuote int
+
+const (
+	Backticks    IdentQuote = iota // `name`, as MySQL does
+	DoubleQuotes                   // "name", as ANSI SQL does
+	Bare                           // name, left unquoted
+)
+
+// Quote returns the identifier s quoted with q
+func (q IdentQuote) Quote(s string) string {
+	switch q {
+	case DoubleQuotes:
+		return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+	case Bare:
+		return s
+	}
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// quoteList quotes each name and joins them with commas
+func (q IdentQuote) quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = q.Quote(name)
+	}
+	return strings.Join(quoted, ",")
+}
+
+func quoteIdent(s string) string {
+	return Backticks.Quote(s)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableSQLRoundTrip(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT PRIMARY KEY,\n  `email` varchar(255) UNIQUE,\n" +
+		"  `name` varchar(20) NOT NULL DEFAULT '' COMMENT 'display name',\n  UNIQUE KEY `uk_name` (`name`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddl := schema["user"].SQL()
+	expected := "CREATE TABLE `user` (\n" +
+		"  `id` bigint(20) NOT NULL AUTO_INCREMENT PRIMARY KEY,\n" +
+		"  `email` varchar(255) UNIQUE KEY,\n" +
+		"  `name` varchar(20) NOT NULL DEFAULT '' COMMENT 'display name',\n" +
+		"  UNIQUE KEY `uk_name` (`name`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8"
+	if ddl != expected {
+		t.Errorf("expected:\n%s\nfound:\n%s", expected, ddl)
+	}
+
+	schema, err = NewParser(strings.NewReader(ddl + ";")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if index := user.Indexes["email"]; index == nil || !index.Inline || index.Kind != UniqueIndex {
+		t.Errorf("expected inline unique key email, found %+v", index)
+	}
+	if index := user.Indexes["uk_name"]; index == nil || index.Inline {
+		t.Errorf("expected separate unique key uk_name, found %+v", index)
+	}
+	if index := user.Indexes["PRIMARY"]; index == nil || !index.Inline {
+		t.Errorf("expected inline primary key, found %+v", index)
+	}
+	if again := user.SQL(); again != ddl {
+		t.Errorf("expected round trip to be stable, found:\n%s", again)
+	}
+}
+
+func TestTableSQLDeclarationOrder(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `b_id` int,\n  `a_id` int,\n  KEY `idx_b` (`b_id`),\n  UNIQUE KEY `uk_a` (`a_id`),\n  PRIMARY KEY (`id`),\n  KEY `idx_a` (`a_id`),\n" +
+		"  CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`),\n  CONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`)\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `b_id` int,\n  `a_id` int,\n  PRIMARY KEY (`id`),\n  KEY `idx_b` (`b_id`),\n  UNIQUE KEY `uk_a` (`a_id`),\n  KEY `idx_a` (`a_id`),\n" +
+		"  CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`),\n  CONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`)\n)"
+	if ddl := schema["user"].SQL(); ddl != expected {
+		t.Errorf("expected:\n%s\nfound:\n%s", expected, ddl)
+	}
+	user := schema["user"]
+	user.IndexOrder, user.ConstraintOrder = nil, nil
+	expected = "CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `b_id` int,\n  `a_id` int,\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `uk_a` (`a_id`),\n  KEY `idx_a` (`a_id`),\n  KEY `idx_b` (`b_id`),\n" +
+		"  CONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`),\n  CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`)\n)"
+	if ddl := user.SQL(); ddl != expected {
+		t.Errorf("expected sorted keys without declaration order:\n%s\nfound:\n%s", expected, ddl)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	dump := "-- dump\nDROP TABLE IF EXISTS `user`;\n" +
+		"CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `city_id` bigint(20) DEFAULT NULL,\n  `name` varchar(20) NOT NULL DEFAULT '' COMMENT 'display name',\n" +
+		"  `uuid` varchar(36) DEFAULT (uuid()),\n  PRIMARY KEY (`id`),\n  KEY `idx_name` (`name`) USING BTREE,\n" +
+		"  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`) ON DELETE CASCADE,\n  CHECK (`id` > 0)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=10 DEFAULT CHARSET=utf8mb4 COMMENT='users';\n" +
+		"CREATE TABLE `city` (\n  `id` bigint(20) NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB;\n"
+	canonical, err := Canonicalize(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(canonical, "CREATE TABLE `city`") {
+		t.Errorf("expected tables sorted by name, found:\n%s", canonical)
+	}
+	again, err := Canonicalize(strings.NewReader(canonical))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != canonical {
+		t.Errorf("expected canonical form to be stable, found:\n%s\nthen:\n%s", canonical, again)
+	}
+
+	original, err := NewParser(strings.NewReader(dump)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reparsed, err := NewParser(strings.NewReader(canonical)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := original.Diff(reparsed); !diff.Empty() {
+		t.Errorf("expected canonical form to parse to the same schema, found %+v", diff)
+	}
+	for name, table := range original {
+		if reparsed[name].SQL() != table.SQL() {
+			t.Errorf("table %s: expected %s, found %s", name, table.SQL(), reparsed[name].SQL())
+		}
+	}
+}
+
+func TestTableSQLQuoted(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `city_id` int,\n  PRIMARY KEY (`id`),\n  KEY `idx_city` (`city_id`),\n" +
+		"  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	expected := map[IdentQuote]string{
+		Backticks: "CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `city_id` int,\n  PRIMARY KEY (`id`),\n  KEY `idx_city` (`city_id`),\n" +
+			"  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`)\n)",
+		DoubleQuotes: "CREATE TABLE \"user\" (\n  \"id\" int NOT NULL,\n  \"city_id\" int,\n  PRIMARY KEY (\"id\"),\n  KEY \"idx_city\" (\"city_id\"),\n" +
+			"  CONSTRAINT \"fk_city\" FOREIGN KEY (\"city_id\") REFERENCES \"city\" (\"id\")\n)",
+		Bare: "CREATE TABLE user (\n  id int NOT NULL,\n  city_id int,\n  PRIMARY KEY (id),\n  KEY idx_city (city_id),\n" +
+			"  CONSTRAINT fk_city FOREIGN KEY (city_id) REFERENCES city (id)\n)",
+	}
+	for q, sql := range expected {
+		if found := user.SQLQuoted(q); found != sql {
+			t.Errorf("%d: expected:\n%s\nfound:\n%s", q, sql, found)
+		}
+	}
+	if user.SQL() != expected[Backticks] {
+		t.Errorf("expected SQL to quote with backticks")
+	}
+	if q := DoubleQuotes.Quote(`a"b`); q != `"a""b"` {
+		t.Errorf("expected escaped double quote, found %s", q)
+	}
+}
@@ -3,13 +3,37 @@ package sqlparser
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"io"
 	"strings"
 )
 
 // Scanner wrapps a buffer reader
 type Scanner struct {
-	r *bufio.Reader
+	// ANSIQuotes makes "..." quote identifiers like `...`, as the MySQL
+	// ANSI_QUOTES SQL mode does, instead of strings
+	ANSIQuotes bool
+	// Delimiter, when set to something other than ;, ends statements
+	// instead of ; the way the mysql client DELIMITER command does. The
+	// delimiter is scanned as SEMI_COLON and a plain ; as ILLEGAL.
+	Delimiter string
+
+	r      *bufio.Reader
+	hist   []scannedRune // runes read by the current Scan, undone by unread
+	back   []scannedRune // runes pushed back by unread, read again first
+	offset int           // bytes consumed so far
+	line   int           // newlines consumed so far
+	col    int           // runes consumed since the last newline
+	quoted bool          // whether the last scanned IDENT was backtick quoted
+	err    error
+}
+
+// scannedRune is a rune read by the scanner, with what unread needs to
+// restore the position before it
+type scannedRune struct {
+	ch   rune
+	size int // 0 for eof
+	col  int // the column before the rune
 }
 
 // Token represents a token
@@ -20,14 +44,16 @@ const (
 	ILLEGAL Token = iota
 	EOF
 	ANNOTATION
-	WS // space, tab and newline
+	WS // space, tab, carriage return and newline
 
 	STRING
 	IDENT // table_name, index, column_name, engine_name, charset_name
 
 	COMMA
+	DOT
 	BACKTICK
 	SEMI_COLON
+	MINUS
 	OPEN_PAREN
 	CLOSE_PAREN
 
@@ -37,16 +63,41 @@ const (
 	TINYINT
 	SMALLINT
 	INT
+	INTEGER
 	BIGINT
+	BOOL
+	BOOLEAN
 	FLOAT
 	DOUBLE
-	LONGTEXT
+	REAL
+	DEC
+	DECIMAL
+	NUMERIC
+	FIXED
+	TINYTEXT
+	TEXT
 	MEDIUMTEXT
+	LONGTEXT
+	TINYBLOB
+	BLOB
+	MEDIUMBLOB
+	LONGBLOB
+	CHAR
 	VARCHAR
 	DATE
 	TIME
 	DATETIME
 	TIMESTAMP
+	ENUM
+	GEOMETRY
+	POINT
+	LINESTRING
+	POLYGON
+	MULTIPOINT
+	MULTILINESTRING
+	MULTIPOLYGON
+	GEOMETRYCOLLECTION
+	JSON
 
 	// SQL keywords
 	DROP
@@ -65,12 +116,40 @@ const (
 	COMMENT
 	KEY
 	UNIQUE
+	FULLTEXT
+	SPATIAL
+	WITH
+	PARSER
+	USING
 	CONSTRAINT
 	PRIMARY
 	FOREIGN
 	REFERENCES
+	CHECK
+	ENFORCED
+	ON
+	DELETE
+	UPDATE
+	CASCADE
+	RESTRICT
+	SET
+	NO
+	ACTION
+	INSERT
+	INTO
+	VALUES
+	ALTER
+	ADD
+	COLUMN
+	MODIFY
+	CONVERT
+	TO
+	CHARACTER
 	AUTO_INCREMENT
 	CURRENT_TIMESTAMP
+	SRID
+	UNSIGNED
+	ZEROFILL
 )
 
 var (
@@ -78,7 +157,12 @@ var (
 )
 
 func isWhitespace(ch rune) bool {
-	return ch == ' ' || ch == '\t' || ch == '\n'
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+// Newlines returns the number of line breaks in a WS literal
+func Newlines(ws string) int {
+	return strings.Count(ws, "\n")
 }
 
 func isLetter(ch rune) bool {
@@ -98,16 +182,59 @@ func NewScanner(r io.Reader) *Scanner {
 	return &Scanner{r: bufio.NewReader(r)}
 }
 
+// read returns the next rune, the runes pushed back by unread first
 func (s *Scanner) read() rune {
-	ch, _, err := s.r.ReadRune()
-	if err != nil {
-		return eof
+	var r scannedRune
+	if n := len(s.back); n > 0 {
+		r, s.back = s.back[n-1], s.back[:n-1]
+	} else if ch, size, err := s.r.ReadRune(); err == nil {
+		r = scannedRune{ch: ch, size: size}
+	} else {
+		r = scannedRune{ch: eof}
 	}
-	return ch
+	r.col = s.col
+	s.hist = append(s.hist, r)
+	s.offset += r.size
+	if r.ch == '\n' {
+		s.line++
+		s.col = 0
+	} else if r.ch != eof {
+		s.col++
+	}
+	return r.ch
 }
 
+// unread pushes back the last read rune, it can be called repeatedly to
+// back up over every rune read since the current Scan started
 func (s *Scanner) unread() {
-	_ = s.r.UnreadRune()
+	n := len(s.hist)
+	if n == 0 {
+		return
+	}
+	r := s.hist[n-1]
+	s.hist = s.hist[:n-1]
+	s.back = append(s.back, r)
+	s.offset -= r.size
+	if r.ch == '\n' {
+		s.line--
+	}
+	s.col = r.col
+}
+
+// Line returns the 1-based line number of the next rune to be scanned
+func (s *Scanner) Line() int {
+	return s.line + 1
+}
+
+// Pos returns the 1-based line and column of the next rune to be scanned,
+// columns count runes
+func (s *Scanner) Pos() (line, col int) {
+	return s.line + 1, s.col + 1
+}
+
+// Offset returns the byte offset of the next rune to be scanned
+func (s *Scanner) Offset() int {
+	return s.offset
 }
 
 func (s *Scanner) scanWhitespace() (tok Token, lit string) {
@@ -142,42 +269,130 @@ func (s *Scanner) scanDigit() (tok Token, lit string) {
 	return SIZE, buf.String()
 }
 
+// backslash escapes of string literals, any other escaped character
+// stands for itself. \% and \_ keep their backslash as MySQL does.
+var stringEscapes = map[rune]string{
+	'0': "\x00",
+	'b': "\b",
+	'n': "\n",
+	'r': "\r",
+	't': "\t",
+	'Z': "\x1a",
+	'%': `\%`,
+	'_': `\_`,
+}
+
+// scanString scans a quoted string or identifier and returns it unescaped,
+// a doubled quote stands for the quote and strings also take backslash
+// escapes
 func (s *Scanner) scanString() (tok Token, lit string) {
 	var buf bytes.Buffer
 	ch := s.read()
-	readString := func(c rune) {
+	readString := func(c rune, backslash bool) bool {
 		for {
-			if ch := s.read(); ch == c {
-				break
-			} else {
-				_, _ = buf.WriteRune(ch)
+			switch ch := s.read(); {
+			case ch == eof:
+				return false
+			case ch == c:
+				if s.read() != c {
+					s.unread()
+					return true
+				}
+				buf.WriteRune(c)
+			case ch == '\\' && backslash:
+				next := s.read()
+				if next == eof {
+					return false
+				}
+				if e, ok := stringEscapes[next]; ok {
+					buf.WriteString(e)
+				} else {
+					buf.WriteRune(next)
+				}
+			default:
+				buf.WriteRune(ch)
 			}
 		}
 	}
+	var terminated bool
 	switch ch {
 	case '`':
 		tok = IDENT
-		readString('`')
+		s.quoted = true
+		terminated = readString('`', false)
 	case '\'':
 		tok = STRING
-		readString('\'')
+		terminated = readString('\'', true)
+	case '"':
+		if s.ANSIQuotes {
+			tok = IDENT
+			s.quoted = true
+		} else {
+			tok = STRING
+		}
+		terminated = readString('"', tok == STRING)
 	default:
 		return ILLEGAL, string(ch)
 	}
+	if !terminated {
+		return ILLEGAL, string(ch) + buf.String()
+	}
 	return tok, buf.String()
 }
 
+// scanInlineComment scans a /* comment, an unterminated comment is ILLEGAL
+// and recorded as the scanner's error
 func (s *Scanner) scanInlineComment() (tok Token, lit string) {
+	s.read() // the opening /*
+	s.read()
+	var prev rune
 	for {
-		if ch := s.read(); ch == eof {
-			return ILLEGAL, ""
-		} else if ch == '*' {
-			if c := s.read(); c == '/' {
-				break
+		ch := s.read()
+		switch {
+		case ch == eof:
+			s.err = errors.New("unterminated /* comment")
+			return ILLEGAL, "/*"
+		case prev == '*' && ch == '/':
+			return ANNOTATION, ""
+		}
+		prev = ch
+	}
+}
+
+// scanLineComment scans the rest of a -- or # comment up to and including
+// the end of the line
+func (s *Scanner) scanLineComment() (tok Token, lit string) {
+	for {
+		if ch := s.read(); ch == '\n' || ch == eof {
+			return ANNOTATION, ""
+		}
+	}
+}
+
+// Err returns the first error found while scanning, e.g. an unterminated
+// comment
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// scanDelimiter reports whether ch starts the custom delimiter and
+// consumes the rest of it if so
+func (s *Scanner) scanDelimiter(ch rune) bool {
+	rest := strings.TrimPrefix(s.Delimiter, string(ch))
+	if ch == eof || len(rest) == len(s.Delimiter) {
+		return false
+	}
+	var n int
+	for _, want := range rest {
+		n++
+		if s.read() != want {
+			for ; n > 0; n-- {
+				s.unread()
 			}
+			return false
 		}
 	}
-	return ANNOTATION, ""
+	return true
 }
 
 func (s *Scanner) scanIdent() (tok Token, lit string) {
@@ -220,10 +435,20 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 		return DEFAULT, buf.String()
 	case "COMMENT":
 		return COMMENT, buf.String()
-	case "KEY":
+	case "KEY", "INDEX":
 		return KEY, buf.String()
 	case "UNIQUE":
 		return UNIQUE, buf.String()
+	case "FULLTEXT":
+		return FULLTEXT, buf.String()
+	case "SPATIAL":
+		return SPATIAL, buf.String()
+	case "WITH":
+		return WITH, buf.String()
+	case "PARSER":
+		return PARSER, buf.String()
+	case "USING":
+		return USING, buf.String()
 	case "CONSTRAINT":
 		return CONSTRAINT, buf.String()
 	case "PRIMARY":
@@ -232,10 +457,56 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 		return FOREIGN, buf.String()
 	case "REFERENCES":
 		return REFERENCES, buf.String()
+	case "CHECK":
+		return CHECK, buf.String()
+	case "ENFORCED":
+		return ENFORCED, buf.String()
+	case "ON":
+		return ON, buf.String()
+	case "DELETE":
+		return DELETE, buf.String()
+	case "UPDATE":
+		return UPDATE, buf.String()
+	case "CASCADE":
+		return CASCADE, buf.String()
+	case "RESTRICT":
+		return RESTRICT, buf.String()
+	case "SET":
+		return SET, buf.String()
+	case "NO":
+		return NO, buf.String()
+	case "ACTION":
+		return ACTION, buf.String()
+	case "INSERT":
+		return INSERT, buf.String()
+	case "INTO":
+		return INTO, buf.String()
+	case "VALUES", "VALUE":
+		return VALUES, buf.String()
+	case "ALTER":
+		return ALTER, buf.String()
+	case "ADD":
+		return ADD, buf.String()
+	case "COLUMN":
+		return COLUMN, buf.String()
+	case "MODIFY":
+		return MODIFY, buf.String()
+	case "CONVERT":
+		return CONVERT, buf.String()
+	case "TO":
+		return TO, buf.String()
+	case "CHARACTER":
+		return CHARACTER, buf.String()
 	case "AUTO_INCREMENT":
 		return AUTO_INCREMENT, buf.String()
 	case "CURRENT_TIMESTAMP":
 		return CURRENT_TIMESTAMP, buf.String()
+	case "SRID":
+		return SRID, buf.String()
+	case "UNSIGNED":
+		return UNSIGNED, buf.String()
+	case "ZEROFILL":
+		return ZEROFILL, buf.String()
 	case "BIT":
 		return BIT, buf.String()
 	case "TINYINT":
@@ -244,14 +515,44 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 		return SMALLINT, buf.String()
 	case "INT":
 		return INT, buf.String()
+	case "INTEGER":
+		return INTEGER, buf.String()
 	case "BIGINT":
 		return BIGINT, buf.String()
+	case "BOOL":
+		return BOOL, buf.String()
+	case "BOOLEAN":
+		return BOOLEAN, buf.String()
 	case "FLOAT":
 		return FLOAT, buf.String()
 	case "DOUBLE":
 		return DOUBLE, buf.String()
+	case "REAL":
+		return REAL, buf.String()
+	case "DEC":
+		return DEC, buf.String()
+	case "DECIMAL":
+		return DECIMAL, buf.String()
+	case "NUMERIC":
+		return NUMERIC, buf.String()
+	case "FIXED":
+		return FIXED, buf.String()
+	case "CHAR":
+		return CHAR, buf.String()
 	case "VARCHAR":
 		return VARCHAR, buf.String()
+	case "TINYTEXT":
+		return TINYTEXT, buf.String()
+	case "TEXT":
+		return TEXT, buf.String()
+	case "TINYBLOB":
+		return TINYBLOB, buf.String()
+	case "BLOB":
+		return BLOB, buf.String()
+	case "MEDIUMBLOB":
+		return MEDIUMBLOB, buf.String()
+	case "LONGBLOB":
+		return LONGBLOB, buf.String()
 	case "LONGTEXT":
 		return LONGTEXT, buf.String()
 	case "MEDIUMTEXT":
@@ -264,15 +565,50 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 		return DATETIME, buf.String()
 	case "TIMESTAMP":
 		return TIMESTAMP, buf.String()
+	case "ENUM":
+		return ENUM, buf.String()
+	case "JSON":
+		return JSON, buf.String()
+	case "GEOMETRY":
+		return GEOMETRY, buf.String()
+	case "POINT":
+		return POINT, buf.String()
+	case "LINESTRING":
+		return LINESTRING, buf.String()
+	case "POLYGON":
+		return POLYGON, buf.String()
+	case "MULTIPOINT":
+		return MULTIPOINT, buf.String()
+	case "MULTILINESTRING":
+		return MULTILINESTRING, buf.String()
+	case "MULTIPOLYGON":
+		return MULTIPOLYGON, buf.String()
+	case "GEOMETRYCOLLECTION":
+		return GEOMETRYCOLLECTION, buf.String()
 	default:
 		return IDENT, buf.String()
 	}
 }
 
+// Quoted reports whether the last scanned IDENT was backtick quoted
+func (s *Scanner) Quoted() bool {
+	return s.quoted
+}
+
 // Scan method scans one token, returns a token and its literal string
 func (s *Scanner) Scan() (tok Token, lit string) {
+	s.quoted = false
+	s.hist = s.hist[:0]
 	ch := s.read()
 
+	if s.Delimiter != "" && s.Delimiter != ";" {
+		if s.scanDelimiter(ch) {
+			return SEMI_COLON, s.Delimiter
+		} else if ch == ';' {
+			return ILLEGAL, string(ch)
+		}
+	}
+
 	if isWhitespace(ch) {
 		s.unread()
 		return s.scanWhitespace()
@@ -282,7 +618,7 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 	} else if isDigit(ch) {
 		s.unread()
 		return s.scanDigit()
-	} else if ch == '\'' || ch == '`' {
+	} else if ch == '\'' || ch == '`' || ch == '"' {
 		s.unread()
 		return s.scanString()
 	} else if ch == '/' {
@@ -300,6 +636,8 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 		return EOF, "EOF"
 	case ',':
 		return COMMA, ","
+	case '.':
+		return DOT, "."
 	case '(':
 		return OPEN_PAREN, "("
 	case ')':
@@ -309,15 +647,39 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 	case '=':
 		return EQUAL, "="
 	case '-':
-		if c := s.read(); c == '-' { // comment
-			for {
-				if c := s.read(); c == '\n' {
-					return ANNOTATION, ""
-				}
+		// a comment needs whitespace or a control character after --
+		if c := s.read(); c == '-' {
+			if c2 := s.read(); c2 <= ' ' { // includes eof
+				s.unread()
+				return s.scanLineComment()
 			}
+			s.unread()
 		}
-		return ILLEGAL, string(ch)
+		s.unread()
+		return MINUS, "-"
+	case '#':
+		return s.scanLineComment()
 	default:
 		return ILLEGAL, string(ch)
 	}
 }
+
+// TransformFunc rewrites a token and its literal string
+type TransformFunc func(tok Token, lit string) (Token, string)
+
+// Transformer wraps a scanner and applies a transform function to every
+// scanned token, e.g. to rename identifiers or blank out comments
+type Transformer struct {
+	s  *Scanner
+	fn TransformFunc
+}
+
+// NewTransformer returns a new transformer for the given scanner
+func NewTransformer(s *Scanner, fn TransformFunc) *Transformer {
+	return &Transformer{s: s, fn: fn}
+}
+
+// Scan scans one token from the underlying scanner and transforms it
+func (t *Transformer) Scan() (tok Token, lit string) {
+	return t.fn(t.s.Scan())
+}
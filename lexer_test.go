@@ -7,7 +7,7 @@ import (
 )
 
 func Test_Lexer(t *testing.T) {
-	sqlStmt := "--this is a comment\nDROP TABLE IF EXISTS `user`;\n/* this is an inline comment */\nCREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `username` varchar(20) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;"
+	sqlStmt := "-- this is a comment\nDROP TABLE IF EXISTS `user`;\n/* this is an inline comment */\nCREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `username` varchar(20) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;"
 	fmt.Printf("%q\n", sqlStmt)
 	s := NewScanner(strings.NewReader(sqlStmt))
 	expectedTokens := []Token{
@@ -45,3 +45,239 @@ func Test_Lexer(t *testing.T) {
 	// }
 	// s := NewScanner(f)
 }
+
+func TestLexerMultiLineString(t *testing.T) {
+	s := NewScanner(strings.NewReader("COMMENT 'line1\nline2\nline3' NOT"))
+	var lit string
+	for {
+		tok, l := s.Scan()
+		if tok == STRING {
+			lit = l
+			break
+		} else if tok == EOF {
+			t.Fatalf("expected STRING, found EOF")
+		}
+	}
+	if lit != "line1\nline2\nline3" {
+		t.Errorf("expected multi-line comment, found %q", lit)
+	}
+	if line := s.Line(); line != 3 {
+		t.Errorf("expected line 3 after string, found %d", line)
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	s := NewScanner(strings.NewReader("CREATE TABLE `user` (`id` int, name int)"))
+	upper := NewTransformer(s, func(tok Token, lit string) (Token, string) {
+		if tok == IDENT {
+			return tok, strings.ToUpper(lit)
+		}
+		return tok, lit
+	})
+	var idents []string
+	for {
+		tok, lit := upper.Scan()
+		if tok == EOF {
+			break
+		}
+		if tok == IDENT {
+			idents = append(idents, lit)
+		}
+	}
+	if strings.Join(idents, ",") != "USER,ID,NAME" {
+		t.Errorf("expected USER,ID,NAME, found %v", idents)
+	}
+}
+
+func TestLexerWhitespace(t *testing.T) {
+	s := NewScanner(strings.NewReader("CREATE \r\n\t\n  TABLE"))
+	s.Scan()
+	tok, lit := s.Scan()
+	if tok != WS || lit != " \r\n\t\n  " {
+		t.Fatalf("expected WS %q, found %v %q", " \r\n\t\n  ", tok, lit)
+	}
+	if n := Newlines(lit); n != 2 {
+		t.Errorf("expected 2 newlines, found %d", n)
+	}
+	if tok, _ := s.Scan(); tok != TABLE {
+		t.Errorf("expected TABLE, found %v", tok)
+	}
+}
+
+func TestLexerDashComment(t *testing.T) {
+	cases := map[string][]Token{
+		"-- comment\nNULL":  {ANNOTATION, NULL},
+		"--\tcomment\nNULL": {ANNOTATION, NULL},
+		"a--5":              {IDENT, MINUS, MINUS, SIZE},
+		"1 -1":              {SIZE, WS, MINUS, SIZE},
+		"NULL -- trailing":  {NULL, WS, ANNOTATION},
+		"--":                {ANNOTATION},
+		"# comment\nNULL":   {ANNOTATION, NULL},
+		"NULL # trailing":   {NULL, WS, ANNOTATION},
+		"#":                 {ANNOTATION},
+		"'#' NULL":          {STRING, WS, NULL},
+	}
+	for input, expected := range cases {
+		s := NewScanner(strings.NewReader(input))
+		var tokens []Token
+		for {
+			tok, _ := s.Scan()
+			if tok == EOF {
+				break
+			}
+			tokens = append(tokens, tok)
+		}
+		if fmt.Sprint(tokens) != fmt.Sprint(expected) {
+			t.Errorf("%q: expected %v, found %v", input, expected, tokens)
+		}
+	}
+}
+
+func TestLexerANSIQuotes(t *testing.T) {
+	sqlStmt := `"user" 'name'`
+	expected := map[bool][]Token{
+		false: {STRING, WS, STRING},
+		true:  {IDENT, WS, STRING},
+	}
+	for ansi, tokens := range expected {
+		s := NewScanner(strings.NewReader(sqlStmt))
+		s.ANSIQuotes = ansi
+		for i, e := range tokens {
+			tok, lit := s.Scan()
+			if tok != e {
+				t.Errorf("ANSIQuotes=%v token %d: expected %v, found %v %q", ansi, i, e, tok, lit)
+			}
+			if i == 0 && (lit != "user" || s.Quoted() != ansi) {
+				t.Errorf("ANSIQuotes=%v: expected user quoted %v, found %q %v", ansi, ansi, lit, s.Quoted())
+			}
+		}
+	}
+}
+
+func TestLexerDelimiter(t *testing.T) {
+	s := NewScanner(strings.NewReader("END;$$ $"))
+	s.Delimiter = "$$"
+	expected := []Token{IDENT, ILLEGAL, SEMI_COLON, WS, ILLEGAL, EOF}
+	for i, e := range expected {
+		if tok, lit := s.Scan(); tok != e {
+			t.Errorf("token %d: expected %v, found %v %q", i, e, tok, lit)
+		}
+	}
+	if s.Offset() != 8 {
+		t.Errorf("expected offset 8, found %d", s.Offset())
+	}
+}
+
+func TestLexerPos(t *testing.T) {
+	s := NewScanner(strings.NewReader("a\n  bé c"))
+	expected := [][2]int{{1, 1}, {1, 2}, {2, 3}, {2, 4}, {2, 5}, {2, 6}}
+	for i, e := range expected {
+		if line, col := s.Pos(); line != e[0] || col != e[1] {
+			t.Errorf("token %d: expected line %d, col %d, found line %d, col %d", i, e[0], e[1], line, col)
+		}
+		s.Scan()
+	}
+}
+
+func TestLexerInlineComment(t *testing.T) {
+	cases := map[string][]Token{
+		"/* a ** / */NULL": {ANNOTATION, NULL},
+		"/* a **/NULL":     {ANNOTATION, NULL},
+		"/*/ a */NULL":     {ANNOTATION, NULL},
+		"/**/NULL":         {ANNOTATION, NULL},
+		"/ NULL":           {ILLEGAL, WS, NULL},
+	}
+	for input, expected := range cases {
+		s := NewScanner(strings.NewReader(input))
+		var tokens []Token
+		for {
+			tok, _ := s.Scan()
+			if tok == EOF {
+				break
+			}
+			tokens = append(tokens, tok)
+		}
+		if fmt.Sprint(tokens) != fmt.Sprint(expected) {
+			t.Errorf("%q: expected %v, found %v", input, expected, tokens)
+		}
+		if s.Err() != nil {
+			t.Errorf("%q: unexpected error %v", input, s.Err())
+		}
+	}
+
+	s := NewScanner(strings.NewReader("/* a *"))
+	if tok, _ := s.Scan(); tok != ILLEGAL || s.Err() == nil {
+		t.Errorf("expected ILLEGAL and an error for an unterminated comment, found %v %v", tok, s.Err())
+	}
+}
+
+func TestLexerStringEscapes(t *testing.T) {
+	cases := map[string]string{
+		`'it''s'`:         "it's",
+		`'it\'s'`:         "it's",
+		`'line1\nline2'`:  "line1\nline2",
+		`'tab\there'`:     "tab\there",
+		`'back\\slash'`:   `back\slash`,
+		`'100\%'`:         `100\%`,
+		`'\q'`:            "q",
+		`"say ""hi"""`:    `say "hi"`,
+		`"it\"s"`:         `it"s`,
+		"`weird``name`":   "weird`name",
+		"`no\\escape`":    `no\escape`,
+		`''`:              "",
+		`''''`:            "'",
+		`'a' 'b'`:         "a",
+		"'unterminated''": "",
+		`'unterminated\'`: "",
+	}
+	for input, expected := range cases {
+		s := NewScanner(strings.NewReader(input))
+		tok, lit := s.Scan()
+		if strings.HasPrefix(input, "'unterminated") {
+			if tok != ILLEGAL {
+				t.Errorf("%s: expected ILLEGAL, found %v %q", input, tok, lit)
+			}
+			continue
+		}
+		if tok != STRING && tok != IDENT || lit != expected {
+			t.Errorf("%s: expected %q, found %v %q", input, expected, tok, lit)
+		}
+	}
+}
+
+func TestLexerUnread(t *testing.T) {
+	// a /* comment is detected by reading two runes and unreading both
+	s := NewScanner(strings.NewReader("a/* c */b /x"))
+	expected := []struct {
+		tok    Token
+		offset int
+	}{{IDENT, 1}, {ANNOTATION, 8}, {IDENT, 9}, {WS, 10}, {ILLEGAL, 11}, {IDENT, 12}, {EOF, 12}}
+	for i, e := range expected {
+		if tok, lit := s.Scan(); tok != e.tok || s.Offset() != e.offset {
+			t.Errorf("token %d: expected %v ending at %d, found %v %q ending at %d", i, e.tok, e.offset, tok, lit, s.Offset())
+		}
+	}
+
+	s = NewScanner(strings.NewReader("x\n"))
+	s.read()
+	s.read()
+	s.read() // eof
+	s.unread()
+	s.unread()
+	s.unread()
+	if line, col := s.Pos(); line != 1 || col != 1 || s.Offset() != 0 {
+		t.Errorf("expected to back up to line 1, col 1, offset 0, found line %d, col %d, offset %d", line, col, s.Offset())
+	}
+	if tok, lit := s.Scan(); tok != IDENT || lit != "x" {
+		t.Errorf("expected x after unreading, found %v %q", tok, lit)
+	}
+
+	// a partial match of a multi-rune delimiter is backed up over
+	s = NewScanner(strings.NewReader("$$x $$y"))
+	s.Delimiter = "$$y"
+	for i, e := range []Token{ILLEGAL, ILLEGAL, IDENT, WS, SEMI_COLON, EOF} {
+		if tok, lit := s.Scan(); tok != e {
+			t.Errorf("delimiter token %d: expected %v, found %v %q", i, e, tok, lit)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warning describes a portability issue found by a linting parser
+type Warning struct {
+	Table  string
+	Column string // empty for table level warnings
+	Msg    string
+}
+
+func (w Warning) String() string {
+	if w.Column != "" {
+		return fmt.Sprintf("%s.%s: %s", w.Table, w.Column, w.Msg)
+	}
+	return fmt.Sprintf("%s: %s", w.Table, w.Msg)
+}
+
+// lintIdent warns when the last scanned table or column name is an
+// unquoted reserved word
+func (p *Parser) lintIdent(table, column string) {
+	if !p.Lint || p.identQuoted {
+		return
+	}
+	name := table
+	if column != "" {
+		name = column
+	}
+	if IsReserved(name) {
+		p.warnings = append(p.warnings, Warning{
+			Table:  table,
+			Column: column,
+			Msg:    fmt.Sprintf("%q is a reserved word and should be quoted", name),
+		})
+	}
+}
+
+// integerTypes are the types whose display width MySQL 8 deprecates
+var integerTypes = map[string]bool{"tinyint": true, "smallint": true, "int": true, "bigint": true}
+
+// lintDeprecated warns about features MySQL 8 deprecates: integer display
+// widths other than tinyint(1), ZEROFILL and the utf8 alias, as the charset
+// or in utf8_* collations, of the table or its columns
+func (p *Parser) lintDeprecated(t *Table) {
+	if !p.Lint || p.Dialect != MySQL {
+		return
+	}
+	for _, msg := range utf8Alias(t.Charset, t.option("COLLATE")) {
+		p.warnings = append(p.warnings, Warning{Table: t.Name, Msg: msg})
+	}
+	for _, name := range t.columnNames() {
+		c := t.Columns[name]
+		for _, msg := range utf8Alias(c.Charset, c.Collation) {
+			p.warnings = append(p.warnings, Warning{Table: t.Name, Column: name, Msg: msg})
+		}
+		typ := CanonicalType(c.Type)
+		if integerTypes[typ] && c.HasSize && !(typ == "tinyint" && c.Size == 1) {
+			p.warnings = append(p.warnings, Warning{
+				Table:  t.Name,
+				Column: name,
+				Msg:    fmt.Sprintf("display width %s(%d) is deprecated", c.Type, c.Size),
+			})
+		}
+		if c.Zerofill {
+			p.warnings = append(p.warnings, Warning{
+				Table:  t.Name,
+				Column: name,
+				Msg:    "ZEROFILL is deprecated",
+			})
+		}
+	}
+}
+
+// utf8Alias returns a warning for each of charset and collation using the
+// deprecated utf8 alias
+func utf8Alias(charset, collation string) []string {
+	var msgs []string
+	if strings.EqualFold(charset, "utf8") {
+		msgs = append(msgs, "charset utf8 is deprecated, use utf8mb4 or utf8mb3")
+	}
+	if strings.EqualFold(collationCharset(collation), "utf8") {
+		msgs = append(msgs, fmt.Sprintf("collation %s is deprecated, use a utf8mb4 or utf8mb3 collation", collation))
+	}
+	return msgs
+}
+
+// IsReserved reports whether word is a MySQL reserved word
+func IsReserved(word string) bool {
+	return reservedWords[strings.ToUpper(word)]
+}
+
+var reservedWords = make(map[string]bool)
+
+func init() {
+	for _, word := range strings.Fields(`
+		ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC ASENSITIVE BEFORE BETWEEN
+		BIGINT BINARY BLOB BOTH BY CALL CASCADE CASE CHANGE CHAR CHARACTER
+		CHECK COLLATE COLUMN CONDITION CONSTRAINT CONTINUE CONVERT CREATE
+		CROSS CUBE CUME_DIST CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP
+		CURRENT_USER CURSOR DATABASE DATABASES DAY_HOUR DAY_MICROSECOND
+		DAY_MINUTE DAY_SECOND DEC DECIMAL DECLARE DEFAULT DELAYED DELETE
+		DENSE_RANK DESC DESCRIBE DETERMINISTIC DISTINCT DISTINCTROW DIV
+		DOUBLE DROP DUAL EACH ELSE ELSEIF EMPTY ENCLOSED ESCAPED EXCEPT
+		EXISTS EXIT EXPLAIN FALSE FETCH FIRST_VALUE FLOAT FLOAT4 FLOAT8 FOR
+		FORCE FOREIGN FROM FULLTEXT FUNCTION GENERATED GET GRANT GROUP
+		GROUPING GROUPS HAVING HIGH_PRIORITY HOUR_MICROSECOND HOUR_MINUTE
+		HOUR_SECOND IF IGNORE IN INDEX INFILE INNER INOUT INSENSITIVE INSERT
+		INT INT1 INT2 INT3 INT4 INT8 INTEGER INTERSECT INTERVAL INTO
+		IO_AFTER_GTIDS IO_BEFORE_GTIDS IS ITERATE JOIN JSON_TABLE KEY KEYS
+		KILL LAG LAST_VALUE LATERAL LEAD LEADING LEAVE LEFT LIKE LIMIT LINEAR
+		LINES LOAD LOCALTIME LOCALTIMESTAMP LOCK LONG LONGBLOB LONGTEXT LOOP
+		LOW_PRIORITY MASTER_BIND MASTER_SSL_VERIFY_SERVER_CERT MATCH MAXVALUE
+		MEDIUMBLOB MEDIUMINT MEDIUMTEXT MIDDLEINT MINUTE_MICROSECOND
+		MINUTE_SECOND MOD MODIFIES NATURAL NOT NO_WRITE_TO_BINLOG NTH_VALUE
+		NTILE NULL NUMERIC OF ON OPTIMIZE OPTIMIZER_COSTS OPTION OPTIONALLY
+		OR ORDER OUT OUTER OUTFILE OVER PARTITION PERCENT_RANK PRECISION
+		PRIMARY PROCEDURE PURGE RANGE RANK READ READS READ_WRITE REAL
+		RECURSIVE REFERENCES REGEXP RELEASE RENAME REPEAT REPLACE REQUIRE
+		RESIGNAL RESTRICT RETURN REVOKE RIGHT RLIKE ROW ROWS ROW_NUMBER
+		SCHEMA SCHEMAS SECOND_MICROSECOND SELECT SENSITIVE SEPARATOR SET SHOW
+		SIGNAL SMALLINT SPATIAL SPECIFIC SQL SQLEXCEPTION SQLSTATE SQLWARNING
+		SQL_BIG_RESULT SQL_CALC_FOUND_ROWS SQL_SMALL_RESULT SSL STARTING
+		STORED STRAIGHT_JOIN SYSTEM TABLE TERMINATED THEN TINYBLOB TINYINT
+		TINYTEXT TO TRAILING TRIGGER TRUE UNDO UNION UNIQUE UNLOCK UNSIGNED
+		UPDATE USAGE USE USING UTC_DATE UTC_TIME UTC_TIMESTAMP VALUES
+		VARBINARY VARCHAR VARCHARACTER VARYING VIRTUAL WHEN WHERE WHILE
+		WINDOW WITH WRITE XOR YEAR_MONTH ZEROFILL`) {
+		reservedWords[word] = true
+	}
+}
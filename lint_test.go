@@ -0,0 +1,81 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintReservedWords(t *testing.T) {
+	sqlStmt := "CREATE TABLE `order` (\n  `id` int,\n  `select` int,\n  group int,\n  name int\n);\nCREATE TABLE rank (`id` int);"
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.Lint = true
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	warnings := p.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, found %v", warnings)
+	}
+	if w := warnings[0]; w.Table != "order" || w.Column != "group" {
+		t.Errorf("expected warning for order.group, found %v", w)
+	}
+	if w := warnings[1]; w.Table != "rank" || w.Column != "" {
+		t.Errorf("expected warning for table rank, found %v", w)
+	}
+
+	p = NewParser(strings.NewReader(sqlStmt))
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Warnings()) != 0 {
+		t.Errorf("expected no warnings without lint, found %v", p.Warnings())
+	}
+}
+
+func TestLintDeprecated(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` int(11) NOT NULL,\n  `age` int,\n  `active` tinyint(1)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;"
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.Lint = true
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	warnings := p.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, found %v", warnings)
+	}
+	if w := warnings[0]; w.Table != "user" || w.Column != "" || !strings.Contains(w.Msg, "utf8") {
+		t.Errorf("expected utf8 warning for table user, found %v", w)
+	}
+	if w := warnings[1]; w.Table != "user" || w.Column != "id" || !strings.Contains(w.Msg, "int(11)") {
+		t.Errorf("expected display width warning for user.id, found %v", w)
+	}
+
+	p = NewParser(strings.NewReader(sqlStmt))
+	p.Lint = true
+	p.Dialect = MariaDB
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Warnings()) != 0 {
+		t.Errorf("expected no deprecation warnings for mariadb, found %v", p.Warnings())
+	}
+
+	p = NewParser(strings.NewReader("CREATE TABLE `post` (\n  `title` varchar(20) CHARACTER SET utf8,\n  `body` text COLLATE utf8_unicode_ci,\n" +
+		"  `slug` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin\n) DEFAULT CHARSET=utf8mb3 COLLATE=utf8_general_ci;"))
+	p.Lint = true
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, w := range p.Warnings() {
+		found = append(found, w.Column+": "+w.Msg)
+	}
+	expected := []string{
+		": collation utf8_general_ci is deprecated, use a utf8mb4 or utf8mb3 collation",
+		"title: charset utf8 is deprecated, use utf8mb4 or utf8mb3",
+		"body: collation utf8_unicode_ci is deprecated, use a utf8mb4 or utf8mb3 collation",
+	}
+	if strings.Join(found, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected warnings:\n%s\nfound:\n%s", strings.Join(expected, "\n"), strings.Join(found, "\n"))
+	}
+}
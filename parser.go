@@ -1,39 +1,125 @@
 package sqlparser
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
 // Column describe column detail information
 type Column struct {
-	Name     string
-	Type     string
-	Size     int
-	Default  interface{}
-	Comment  string
-	Nullable bool
-	AutoIncr bool
+	Name       string
+	Type       string
+	Size       int
+	HasSize    bool     // whether Size was given, e.g. datetime(0)
+	Scale      int      // digits after the decimal point, e.g. 2 for decimal(10,2)
+	EnumValues []string // members of enum, in declaration order
+	SetValues  []string // members of set, in declaration order
+	SRID       int      // spatial reference system of spatial columns, e.g. 4326
+	Unsigned   bool
+	Zerofill   bool        // ZEROFILL implies Unsigned
+	Default    interface{} // string, int64, uint64, Number, bool, Expr, "null" or "current_timestamp[(n)]"
+	OnUpdate   string      // "current_timestamp" for ON UPDATE CURRENT_TIMESTAMP, "current_timestamp(3)" for CURRENT_TIMESTAMP(3)
+	Comment    string
+	Charset    string // CHARACTER SET of the column, empty to inherit the table charset
+	Collation  string // COLLATE of the column, empty to inherit
+	Generated  Expr   // expression of a generated column, empty for ordinary columns
+	Stored     bool   // the generated column is STORED rather than VIRTUAL
+	Nullable   bool
+	AutoIncr   bool
+	Metadata   map[string]string // key=value pairs from Comment, see Parser.MetadataSeparator
 }
 
+// Expr is an SQL expression, e.g. the value of DEFAULT (uuid())
+type Expr string
+
+// Number is a numeric literal with a fraction or an exponent kept as
+// written, e.g. the 0.00 of DEFAULT 0.00 whose scale a float would lose
+type Number string
+
 // Constraint holds foreign key constraint
 type Constraint struct {
-	Index      string
-	ForeignKey string
-	TableName  string
-	ColumnName string
+	Index              string
+	ForeignKey         []string
+	ReferencedDatabase string // set when the referenced table is qualified as db.table
+	TableName          string
+	ColumnName         []string // referenced columns, matching ForeignKey one to one
+	OnDelete           string   // e.g. CASCADE or SET NULL, empty when omitted (MySQL applies RESTRICT)
+	OnUpdate           string   // e.g. CASCADE or SET NULL, empty when omitted (MySQL applies RESTRICT)
+}
+
+// Check holds a CHECK constraint
+type Check struct {
+	Name     string
+	Expr     string
+	Enforced bool // false for CHECK (expr) NOT ENFORCED
+}
+
+// IndexKind tells primary, unique, plain, fulltext and spatial keys apart
+type IndexKind int
+
+const (
+	KeyIndex IndexKind = iota
+	UniqueIndex
+	PrimaryIndex
+	FulltextIndex
+	SpatialIndex
+)
+
+// btree reports whether keys of the kind are B-trees usable for lookups by
+// leading columns, FULLTEXT and SPATIAL keys are not
+func (k IndexKind) btree() bool {
+	return k != FulltextIndex && k != SpatialIndex
+}
+
+// KeyPart is one element of an index, either a column or an expression
+type KeyPart struct {
+	Column string
+	Length int    // prefix length, e.g. 191 for `name`(191), 0 for the whole column
+	Expr   string // functional key part, e.g. CAST(data AS CHAR(10))
+}
+
+// String returns the column name, or the expression enclosed in parens
+func (k KeyPart) String() string {
+	if k.Expr != "" {
+		return "(" + k.Expr + ")"
+	}
+	return k.Column
+}
+
+// Index holds a key definition
+type Index struct {
+	Name    string // PRIMARY for the primary key
+	Kind    IndexKind
+	Parts   []KeyPart
+	Using   string            // index method, e.g. BTREE
+	Options map[string]string // e.g. KEY_BLOCK_SIZE -> 4, WITH PARSER -> ngram
+	Inline  bool              // declared as a column attribute, e.g. `id` int PRIMARY KEY
 }
 
 // Table is table schema
 type Table struct {
-	Name        string
-	Columns     map[string]*Column
-	PrimaryKey  string
-	UniqueKeys  map[string]string
-	Keys        map[string]string // index -> column_name
-	Constraints map[string]*Constraint
-	Extras      map[string]string
+	Database     string // set when the name is qualified as db.table
+	Name         string
+	Columns      map[string]*Column
+	PrimaryKey   string   // Deprecated: first column of PrimaryKeys
+	PrimaryKeys  []string // primary key columns in key order
+	UniqueKeys   map[string][]string
+	Keys         map[string][]string    // index -> column_names or (expression) in key order
+	FulltextKeys map[string][]string    // FULLTEXT index -> column_names
+	SpatialKeys  map[string][]string    // SPATIAL index -> column_names
+	Indexes      map[string]*Index      // index -> full key definition
+	Constraints  map[string]*Constraint // constraint name -> foreign key, unnamed ones are named table_ibfk_n
+	Checks       map[string]*Check      // unnamed checks are named table_chk_n
+	Charset      string                 // DEFAULT CHARSET as written in the dump
+	Extras       map[string]string
+	RowCount     int // rows inserted by INSERT statements, set by Parse
+
+	ColumnOrder     []string // column names in declaration order
+	IndexOrder      []string // index names in declaration order
+	ConstraintOrder []string // constraint names in declaration order
 }
 
 // Schema stores table name and its schema
@@ -41,11 +127,36 @@ type Schema map[string]*Table
 
 // Parser stores parser state
 type Parser struct {
+	// Dialect selects dialect specific behaviour, defaults to MySQL
+	Dialect Dialect
+	// Strict rejects definitions MySQL would refuse, e.g. NOT NULL DEFAULT NULL
+	Strict bool
+	// CanonicalTypes maps type synonyms to their canonical name, e.g. INTEGER to int
+	CanonicalTypes bool
+	// Lint collects portability warnings, see Warnings
+	Lint bool
+	// MetadataSeparator, when set, splits column comments such as
+	// 'pii=true;encrypted=false' into Column.Metadata
+	MetadataSeparator string
+	// ANSIQuotes reads "..." as identifiers, for dumps of servers running
+	// with the ANSI_QUOTES SQL mode, see Scanner.ANSIQuotes
+	ANSIQuotes bool
+
+	warnings    []Warning
+	rowCounts   map[string]int // table -> rows inserted
+	skipped     []string       // leading keyword of every skipped statement
+	dropped     []string       // tables of DROP TABLE statements
+	identQuoted bool           // whether the last ident returned by scanIdent was quoted
+
 	s   *Scanner
 	buf struct {
-		tok Token
-		lit string
-		n   int
+		tok    Token
+		lit    string
+		off    int // offset of the buffered token
+		line   int // position of the buffered token
+		col    int
+		quoted bool
+		n      int
 	}
 }
 
@@ -58,16 +169,42 @@ func init() {
 	Type[TINYINT] = "tinyint"
 	Type[SMALLINT] = "smallint"
 	Type[INT] = "int"
+	Type[INTEGER] = "integer"
 	Type[BIGINT] = "bigint"
+	Type[BOOL] = "bool"
+	Type[BOOLEAN] = "boolean"
 	Type[FLOAT] = "float"
 	Type[DOUBLE] = "double"
+	Type[REAL] = "real"
+	Type[DEC] = "dec"
+	Type[DECIMAL] = "decimal"
+	Type[NUMERIC] = "numeric"
+	Type[FIXED] = "fixed"
+	Type[CHAR] = "char"
 	Type[VARCHAR] = "varchar"
+	Type[TINYTEXT] = "tinytext"
+	Type[TEXT] = "text"
 	Type[LONGTEXT] = "longtext"
+	Type[TINYBLOB] = "tinyblob"
+	Type[BLOB] = "blob"
+	Type[MEDIUMBLOB] = "mediumblob"
+	Type[LONGBLOB] = "longblob"
 	Type[MEDIUMTEXT] = "mediumtext"
 	Type[DATE] = "date"
 	Type[TIME] = "time"
 	Type[DATETIME] = "datetime"
 	Type[TIMESTAMP] = "timestamp"
+	Type[ENUM] = "enum"
+	Type[SET] = "set"
+	Type[GEOMETRY] = "geometry"
+	Type[POINT] = "point"
+	Type[LINESTRING] = "linestring"
+	Type[POLYGON] = "polygon"
+	Type[MULTIPOINT] = "multipoint"
+	Type[MULTILINESTRING] = "multilinestring"
+	Type[MULTIPOLYGON] = "multipolygon"
+	Type[GEOMETRYCOLLECTION] = "geometrycollection"
+	Type[JSON] = "json"
 }
 
 // NewParser returns a new parser for given reader
@@ -80,8 +217,11 @@ func (p *Parser) scan() (tok Token, lit string) {
 		p.buf.n = 0
 		return p.buf.tok, p.buf.lit
 	}
+	p.buf.off = p.s.Offset()
+	p.buf.line, p.buf.col = p.s.Pos()
+	p.s.ANSIQuotes = p.ANSIQuotes
 	tok, lit = p.s.Scan()
-	p.buf.tok, p.buf.lit = tok, lit
+	p.buf.tok, p.buf.lit, p.buf.quoted = tok, lit, p.s.Quoted()
 	return
 }
 
@@ -89,12 +229,14 @@ func (p *Parser) unscan() {
 	p.buf.n = 1
 }
 
+// scanIgnoreWhitespace scans the next token skipping any whitespace and
+// comments before it
 func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string) {
-	tok, lit = p.scan()
-	if tok == WS || tok == ANNOTATION {
-		tok, lit = p.scan()
+	for {
+		if tok, lit = p.scan(); tok != WS && tok != ANNOTATION {
+			return
+		}
 	}
-	return
 }
 
 func (p *Parser) scanIdent() (tok Token, lit string) {
@@ -102,61 +244,235 @@ func (p *Parser) scanIdent() (tok Token, lit string) {
 	if tok != IDENT {
 		return ILLEGAL, lit
 	}
+	p.identQuoted = p.buf.quoted
 	return tok, lit
 }
 
-func (p *Parser) scanType() (string, int, error) {
+// scanQualifiedIdent scans `name` or `db`.`name`
+func (p *Parser) scanQualifiedIdent() (db, name string, err error) {
+	tok, lit := p.scanIdent()
+	if tok != IDENT {
+		return "", "", fmt.Errorf("found %q, expected ident", lit)
+	}
+	if tok, _ = p.scan(); tok != DOT {
+		p.unscan()
+		return "", lit, nil
+	}
+	db = lit
+	if tok, lit = p.scan(); tok != IDENT {
+		return "", "", fmt.Errorf("found %q, expected ident after %q.", lit, db)
+	}
+	p.identQuoted = p.buf.quoted
+	return db, lit, nil
+}
+
+// scanType scans type[(size[,scale])] or enum('a','b') and sets the type
+// of column
+func (p *Parser) scanType(column *Column) error {
 	tok, lit := p.scanIgnoreWhitespace()
-	if tok >= BIT && tok <= TIMESTAMP {
-		tok1, lit1 := p.scanIgnoreWhitespace()
-		if tok1 != OPEN_PAREN {
-			p.unscan()
-			return Type[tok], 0, nil
+	if _, ok := Type[tok]; !ok {
+		return fmt.Errorf("found %q, expected type", lit)
+	}
+	column.Type = Type[tok]
+	if tok == ENUM || tok == SET {
+		values, err := p.scanEnum()
+		if err != nil {
+			return err
+		}
+		if tok == ENUM {
+			column.EnumValues = values
+		} else {
+			column.SetValues = values
+		}
+		return nil
+	}
+	tok1, lit1 := p.scanIgnoreWhitespace()
+	if tok1 != OPEN_PAREN {
+		p.unscan()
+		return nil
+	}
+	tok2, lit2 := p.scanIgnoreWhitespace()
+	tok3, lit3 := p.scanIgnoreWhitespace()
+	if tok2 != SIZE || (tok3 != CLOSE_PAREN && tok3 != COMMA) {
+		return fmt.Errorf("found %q, expected type(integer)", lit+lit1+lit2+lit3)
+	}
+	column.Size, _ = strconv.Atoi(lit2)
+	column.HasSize = true
+	if tok3 == COMMA {
+		tok4, lit4 := p.scanIgnoreWhitespace()
+		tok5, lit5 := p.scanIgnoreWhitespace()
+		if tok4 != SIZE || tok5 != CLOSE_PAREN {
+			return fmt.Errorf("found %q, expected type(integer,integer)", lit+lit1+lit2+lit3+lit4+lit5)
+		}
+		column.Scale, _ = strconv.Atoi(lit4)
+	}
+	return nil
+}
+
+// scanName scans the charset or collation name following its keyword,
+// MySQL also accepts the name as a string, e.g. COLLATE 'utf8mb4_bin'
+func (p *Parser) scanName(kind string) (string, error) {
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok != IDENT && tok != STRING {
+		return "", fmt.Errorf("found %q, expected %s name", lit, kind)
+	}
+	return lit, nil
+}
+
+// scanEnum scans the ('value', ...) members of ENUM and SET in declaration
+// order, members are strings even when they look like numbers
+func (p *Parser) scanEnum() ([]string, error) {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != OPEN_PAREN {
+		return nil, fmt.Errorf("found %q, expected ('value', ...)", lit)
+	}
+	var values []string
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		if tok != STRING {
+			return nil, fmt.Errorf("found %q, expected 'value'", lit)
 		}
-		tok2, lit2 := p.scanIgnoreWhitespace()
-		tok3, lit3 := p.scanIgnoreWhitespace()
-		if tok2 != SIZE || tok3 != CLOSE_PAREN {
-			return "", 0, fmt.Errorf("found %q, expected type(integer)", lit+lit1+lit2+lit3)
+		values = append(values, lit)
+		switch tok, lit = p.scanIgnoreWhitespace(); tok {
+		case COMMA:
+		case CLOSE_PAREN:
+			return values, nil
+		default:
+			return nil, fmt.Errorf("found %q, expected , or )", lit)
 		}
-		size, _ := strconv.Atoi(lit2)
-		return Type[tok], size, nil
 	}
-	return "", 0, fmt.Errorf("found %q, expected type", lit)
 }
 
-func (p *Parser) scanDefault() (string, error) {
+func (p *Parser) scanDefault() (interface{}, error) {
 	tok, lit := p.scanIgnoreWhitespace()
 	if tok != DEFAULT {
-		return "", fmt.Errorf("found %q, expected DEFAULT", lit)
+		return nil, fmt.Errorf("found %q, expected DEFAULT", lit)
 	}
 	tok, lit = p.scanIgnoreWhitespace()
 	switch tok {
 	case NULL:
 		return "null", nil
 	case CURRENT_TIMESTAMP:
-		return "current_timestamp", nil
+		return p.scanPrecision("current_timestamp")
 	case STRING:
 		return lit, nil
+	case SIZE, MINUS, DOT:
+		return p.scanNumber(tok, lit)
+	case IDENT:
+		if strings.EqualFold(lit, "TRUE") || strings.EqualFold(lit, "FALSE") {
+			return strings.EqualFold(lit, "TRUE"), nil
+		}
+	case OPEN_PAREN: // expression default, MySQL 8
+		expr, err := p.scanExpr()
+		if err != nil {
+			return nil, err
+		}
+		return Expr(expr), nil
+	case EOF:
+		return nil, fmt.Errorf("unexpected EOF after DEFAULT")
+	}
+	return nil, fmt.Errorf("found %q, expected NULL or value", lit)
+}
+
+// scanPrecision scans the optional fractional seconds precision (n) or ()
+// following the function fn, and returns fn with the precision if any
+func (p *Parser) scanPrecision(fn string) (string, error) {
+	if tok, _ := p.scanIgnoreWhitespace(); tok != OPEN_PAREN {
+		p.unscan()
+		return fn, nil
+	}
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok == CLOSE_PAREN {
+		return fn, nil
+	}
+	if tok1, lit1 := p.scanIgnoreWhitespace(); tok != SIZE || tok1 != CLOSE_PAREN {
+		return "", fmt.Errorf("found %q, expected (precision)", lit+lit1)
+	}
+	return fn + "(" + lit + ")", nil
+}
+
+// scanNumber scans the rest of a numeric literal starting with tok, e.g.
+// 1, -1, 0.50, -.5, 1. or 1.5e-3, and returns it as an int64, or a uint64
+// when too large, or a Number when it has a fraction or an exponent
+func (p *Parser) scanNumber(tok Token, lit string) (interface{}, error) {
+	var buf bytes.Buffer
+	if tok == MINUS { // unary minus, MySQL allows whitespace after it
+		buf.WriteString(lit)
+		tok, lit = p.scanIgnoreWhitespace()
+	}
+	digits := tok == SIZE
+	if digits {
+		buf.WriteString(lit)
+		tok, lit = p.scan()
+	}
+	fraction := tok == DOT
+	if fraction {
+		buf.WriteString(lit)
+		if tok, lit = p.scan(); tok == SIZE {
+			buf.WriteString(lit)
+			digits = true
+			tok, lit = p.scan()
+		}
+		if !digits {
+			return nil, fmt.Errorf("found %q, expected number", buf.String()+lit)
+		}
+	}
+	exponent := digits && tok == IDENT && (lit[0] == 'e' || lit[0] == 'E')
+	if exponent {
+		buf.WriteString(lit)
+		if len(lit) == 1 { // the sign of e-3 or e+3 ends the identifier
+			if tok, lit = p.scan(); tok == MINUS || lit == "+" {
+				buf.WriteString(lit)
+				tok, lit = p.scan()
+			}
+			if tok != SIZE {
+				return nil, fmt.Errorf("found %q, expected exponent", buf.String()+lit)
+			}
+			buf.WriteString(lit)
+		}
+		tok, lit = p.scan()
+	}
+	p.unscan()
+	if !fraction && !exponent {
+		if n, err := strconv.ParseInt(buf.String(), 10, 64); err == nil {
+			return n, nil
+		} else if n, err := strconv.ParseUint(buf.String(), 10, 64); err == nil {
+			return n, nil
+		}
+		return nil, fmt.Errorf("found %q, expected number", buf.String()+lit)
+	}
+	if _, err := strconv.ParseFloat(buf.String(), 64); err != nil {
+		return nil, fmt.Errorf("found %q, expected number", buf.String())
 	}
-	return "", fmt.Errorf("found %q, expected NULL or value", lit)
+	return Number(buf.String()), nil
 }
 
-func (p *Parser) scanColumn() (*Column, error) {
-	var column = &Column{}
+func (p *Parser) scanColumn(table *Table) (*Column, error) {
+	var column = &Column{Nullable: true}
+	var notNull, defaultNull bool
 	tok, lit := p.scanIdent()
 	if tok != IDENT {
 		return nil, fmt.Errorf("found %q, expected ident", lit)
 	}
 	column.Name = lit
-	t, s, err := p.scanType()
+	p.lintIdent(table.Name, column.Name)
+	err := p.scanType(column)
 	if err != nil {
 		return nil, err
 	}
-	column.Type = t
-	column.Size = s
+	if p.CanonicalTypes {
+		if c := CanonicalType(column.Type); c != column.Type {
+			if c == "tinyint" && !column.HasSize { // BOOL is tinyint(1)
+				column.Size, column.HasSize = 1, true
+			}
+			column.Type = c
+		}
+	}
 
 	for {
 		tok, lit = p.scanIgnoreWhitespace()
+		if tok == IDENT && (strings.EqualFold(lit, "FIRST") || strings.EqualFold(lit, "AFTER")) {
+			tok = COMMA // the position ends the definition in ALTER TABLE
+		}
 		switch tok {
 		case DEFAULT:
 			p.unscan()
@@ -165,7 +481,7 @@ func (p *Parser) scanColumn() (*Column, error) {
 				return nil, err
 			}
 			column.Default = val
-			column.Nullable = val == "null"
+			defaultNull = val == "null"
 		case NULL:
 			column.Nullable = true
 		case NOT:
@@ -174,6 +490,7 @@ func (p *Parser) scanColumn() (*Column, error) {
 				return nil, fmt.Errorf("found %q, expected NULL", lit1)
 			}
 			column.Nullable = false
+			notNull = true
 		case COMMENT:
 			if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 == STRING {
 				column.Comment = lit1
@@ -182,8 +499,145 @@ func (p *Parser) scanColumn() (*Column, error) {
 			}
 		case AUTO_INCREMENT:
 			column.AutoIncr = true
-		case COMMA, CLOSE_PAREN:
+		case UNSIGNED, ZEROFILL:
+			column.Unsigned = true
+			column.Zerofill = column.Zerofill || tok == ZEROFILL
+			// tolerate the size after the attribute, e.g. int unsigned(10)
+			if tok1, _ := p.scanIgnoreWhitespace(); tok1 == OPEN_PAREN && !column.HasSize {
+				tok2, lit2 := p.scanIgnoreWhitespace()
+				tok3, lit3 := p.scanIgnoreWhitespace()
+				if tok2 != SIZE || tok3 != CLOSE_PAREN {
+					return nil, fmt.Errorf("found %q, expected (integer)", lit2+lit3)
+				}
+				column.Size, _ = strconv.Atoi(lit2)
+				column.HasSize = true
+			} else {
+				p.unscan()
+			}
+		case SRID:
+			tok1, lit1 := p.scanIgnoreWhitespace()
+			if tok1 != SIZE {
+				return nil, fmt.Errorf("found %q, expected SRID integer", lit1)
+			}
+			column.SRID, _ = strconv.Atoi(lit1)
+		case ON:
+			tok1, lit1 := p.scanIgnoreWhitespace()
+			tok2, lit2 := p.scanIgnoreWhitespace()
+			if tok1 != UPDATE || tok2 != CURRENT_TIMESTAMP {
+				return nil, fmt.Errorf("found %q, expected ON UPDATE CURRENT_TIMESTAMP", lit1+lit2)
+			}
+			onUpdate, err := p.scanPrecision("current_timestamp")
+			if err != nil {
+				return nil, err
+			}
+			column.OnUpdate = onUpdate
+		case CHARACTER:
+			if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != SET {
+				return nil, fmt.Errorf("found %q, expected CHARACTER SET", lit1)
+			}
+			if column.Charset, err = p.scanName("charset"); err != nil {
+				return nil, err
+			}
+		case IDENT: // CHARSET, COLLATE or [GENERATED ALWAYS] AS (expr) [VIRTUAL | STORED]
+			if strings.EqualFold(lit, "CHARSET") {
+				if column.Charset, err = p.scanName("charset"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if strings.EqualFold(lit, "COLLATE") {
+				if column.Collation, err = p.scanName("collation"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if strings.EqualFold(lit, "GENERATED") {
+				tok1, lit1 := p.scanIgnoreWhitespace()
+				tok2, lit2 := p.scanIgnoreWhitespace()
+				if tok1 != IDENT || !strings.EqualFold(lit1, "ALWAYS") || tok2 != IDENT || !strings.EqualFold(lit2, "AS") {
+					return nil, fmt.Errorf("found %q, expected GENERATED ALWAYS AS", lit1+lit2)
+				}
+			} else if !strings.EqualFold(lit, "AS") {
+				return nil, fmt.Errorf("found %q, expected column constraint", lit)
+			}
+			if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != OPEN_PAREN {
+				return nil, fmt.Errorf("found %q, expected (", lit1)
+			}
+			expr, err := p.scanExpr()
+			if err != nil {
+				return nil, err
+			}
+			column.Generated = Expr(expr)
+			tok1, lit1 := p.scanIgnoreWhitespace()
+			switch {
+			case tok1 == IDENT && (strings.EqualFold(lit1, "STORED") || strings.EqualFold(lit1, "PERSISTENT")):
+				column.Stored = true
+			case tok1 == IDENT && strings.EqualFold(lit1, "VIRTUAL"):
+			default:
+				p.unscan()
+			}
+		case EQUAL: // malformed dumps write e.g. AUTO_INCREMENT=5 on a column
+			if p.Strict {
+				return nil, fmt.Errorf("found %q, expected column constraint", lit)
+			}
+			p.scanIgnoreWhitespace() // skip the value
+		case PRIMARY, KEY: // KEY alone is a synonym of PRIMARY KEY here
+			if tok == PRIMARY {
+				if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != KEY {
+					return nil, fmt.Errorf("found %q, expected PRIMARY KEY", lit1)
+				}
+			}
+			if err = table.addNewIndex(&Index{
+				Name:   "PRIMARY",
+				Kind:   PrimaryIndex,
+				Parts:  []KeyPart{{Column: column.Name}},
+				Inline: true,
+			}); err != nil {
+				return nil, err
+			}
+		case UNIQUE:
+			if tok1, _ := p.scanIgnoreWhitespace(); tok1 != KEY {
+				p.unscan()
+			}
+			if err = table.addNewIndex(&Index{
+				Name:   table.indexName(column.Name),
+				Kind:   UniqueIndex,
+				Parts:  []KeyPart{{Column: column.Name}},
+				Inline: true,
+			}); err != nil {
+				return nil, err
+			}
+		case REFERENCES: // inline foreign key, named like an unnamed table level one
+			cos := &Constraint{ForeignKey: []string{column.Name}}
+			if err = p.scanReferences(cos); err != nil {
+				return nil, err
+			}
+			table.addConstraint(cos)
+		case CONSTRAINT, CHECK:
+			var name string
+			if tok == CONSTRAINT {
+				p.unscan()
+				if name, err = p.scanConstraintName(); err != nil {
+					return nil, err
+				}
+				if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != CHECK {
+					return nil, fmt.Errorf("found %q, expected CHECK", lit1)
+				}
+			}
+			check, err := p.scanCheck()
+			if err != nil {
+				return nil, err
+			}
+			check.Name = name
+			table.addCheck(check)
+		case COMMA, CLOSE_PAREN, SEMI_COLON:
 			p.unscan()
+			if p.Strict && notNull && defaultNull {
+				return nil, fmt.Errorf("column %q is NOT NULL but has DEFAULT NULL", column.Name)
+			}
+			if p.MetadataSeparator != "" && column.Comment != "" {
+				column.Metadata = ParseCommentMetadata(column.Comment, p.MetadataSeparator)
+			}
 			return column, nil
 		case EOF:
 			return nil, fmt.Errorf("unexpected EOF")
@@ -193,180 +647,600 @@ func (p *Parser) scanColumn() (*Column, error) {
 	}
 }
 
-func (p *Parser) scanPrimaryKey() (string, error) {
+func (p *Parser) scanPrimaryKey() (*Index, error) {
 	tok1, lit1 := p.scanIgnoreWhitespace()
 	tok2, lit2 := p.scanIgnoreWhitespace()
 	if tok1 != PRIMARY || tok2 != KEY {
-		return "", fmt.Errorf("found %q, expected PRIMARY KEY", lit1+lit2)
+		return nil, fmt.Errorf("found %q, expected PRIMARY KEY", lit1+lit2)
 	}
-	tok, lit := p.scanIgnoreWhitespace()
-	if tok == OPEN_PAREN {
+	index := &Index{Name: "PRIMARY", Kind: PrimaryIndex}
+	if tok, _ := p.scanIgnoreWhitespace(); tok == USING {
+		if err := p.scanUsing(index); err != nil {
+			return nil, err
+		}
+	} else {
 		p.unscan()
-		tok, lit = p.scanParenIdent()
+	}
+	parts, err := p.scanKeyParts()
+	if err != nil {
+		return nil, err
+	}
+	index.Parts = parts
+	if err = p.scanIndexOptions(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// scanIdentList scans a parenthesized list of column names
+func (p *Parser) scanIdentList() ([]string, error) {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != OPEN_PAREN {
+		return nil, fmt.Errorf("found %q, expected (`column_name`, ...)", lit)
+	}
+	var names []string
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
 		if tok != IDENT {
-			return "", fmt.Errorf("found %q, expected ident", lit)
+			return nil, fmt.Errorf("found %q, expected `column_name`", lit)
+		}
+		names = append(names, lit)
+		switch tok, lit = p.scanIgnoreWhitespace(); tok {
+		case COMMA:
+		case CLOSE_PAREN:
+			return names, nil
+		default:
+			return nil, fmt.Errorf("found %q, expected , or )", lit)
 		}
-		return lit, nil
 	}
-	tok, lit = p.scanIdent()
-	if tok != IDENT {
-		return "", fmt.Errorf("found %q, expected ident", lit)
+}
+
+// scanExpr scans an expression up to its balanced closing paren, the
+// opening paren must have been consumed already
+func (p *Parser) scanExpr() (string, error) {
+	var buf bytes.Buffer
+	depth := 0
+	for {
+		tok, lit := p.scan()
+		switch tok {
+		case OPEN_PAREN:
+			depth++
+		case CLOSE_PAREN:
+			if depth == 0 {
+				return strings.TrimSpace(buf.String()), nil
+			}
+			depth--
+		case STRING:
+			lit = quoteString(lit)
+		case WS:
+			lit = " "
+		case ANNOTATION:
+			continue
+		case EOF:
+			return "", fmt.Errorf("unexpected EOF, expected )")
+		}
+		buf.WriteString(lit)
 	}
-	return lit, nil
 }
 
-func (p *Parser) scanParenIdent() (Token, string) {
-	tok, lit := p.scanIgnoreWhitespace()
-	if tok != OPEN_PAREN {
-		return ILLEGAL, lit
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(s) + "'"
+}
+
+// scanKeyParts scans the comma separated (part, ...) of a key definition,
+// a part is a column with an optional prefix length or an (expression)
+func (p *Parser) scanKeyParts() ([]KeyPart, error) {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != OPEN_PAREN {
+		return nil, fmt.Errorf("found %q, expected (", lit)
 	}
-	tok, lit = p.scanIgnoreWhitespace()
-	if tok == IDENT {
-		tok1, lit1 := p.scanIgnoreWhitespace()
-		if tok1 != CLOSE_PAREN {
-			return ILLEGAL, lit + lit1
+	var parts []KeyPart
+	for {
+		var part KeyPart
+		tok, lit := p.scanIgnoreWhitespace()
+		switch tok {
+		case IDENT:
+			part.Column = lit
+			if tok, _ = p.scanIgnoreWhitespace(); tok == OPEN_PAREN {
+				tok1, lit1 := p.scanIgnoreWhitespace()
+				tok2, lit2 := p.scanIgnoreWhitespace()
+				if tok1 != SIZE || tok2 != CLOSE_PAREN {
+					return nil, fmt.Errorf("found %q, expected (length)", lit1+lit2)
+				}
+				if part.Length, _ = strconv.Atoi(lit1); part.Length == 0 {
+					return nil, fmt.Errorf("key part %q length cannot be 0", part.Column)
+				}
+			} else {
+				p.unscan()
+			}
+		case OPEN_PAREN: // functional key part
+			expr, err := p.scanExpr()
+			if err != nil {
+				return nil, err
+			}
+			part.Expr = expr
+		default:
+			return nil, fmt.Errorf("found %q, expected ident or (expression)", lit)
+		}
+		parts = append(parts, part)
+		switch tok, lit = p.scanIgnoreWhitespace(); tok {
+		case COMMA:
+		case CLOSE_PAREN:
+			return parts, nil
+		default:
+			return nil, fmt.Errorf("found %q, expected , or )", lit)
 		}
-		return tok, lit
 	}
-	return ILLEGAL, ""
 }
 
-func (p *Parser) scanKey() (string, string, error) {
-	var index, column string
-	tok, lit := p.scanIgnoreWhitespace()
-	if tok != KEY {
-		return "", "", fmt.Errorf("found %q, expected KEY", lit)
+// scanIndex scans an index definition whose leading PRIMARY, UNIQUE,
+// FULLTEXT or KEY token tok was just scanned
+func (p *Parser) scanIndex(tok Token) (*Index, error) {
+	switch tok {
+	case PRIMARY:
+		p.unscan()
+		return p.scanPrimaryKey()
+	case KEY:
+		p.unscan()
+		return p.scanKey()
 	}
-	// parse index
-	tok, lit = p.scanIgnoreWhitespace()
-	if tok == IDENT {
-		index = lit
-	} else {
-		return "", "", fmt.Errorf("found %q, expected index", lit)
+	// INDEX or KEY is optional after UNIQUE, FULLTEXT and SPATIAL
+	if tok1, _ := p.scanIgnoreWhitespace(); tok1 != KEY {
+		p.unscan()
 	}
-	// parse column
-	tok, lit = p.scanIgnoreWhitespace()
-	if tok == IDENT {
-		column = lit
-	} else if tok == OPEN_PAREN {
+	index, err := p.scanKeyDefinition()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case UNIQUE:
+		index.Kind = UniqueIndex
+	case FULLTEXT:
+		index.Kind = FulltextIndex
+	case SPATIAL:
+		index.Kind = SpatialIndex
+	}
+	return index, nil
+}
+
+func (p *Parser) scanKey() (*Index, error) {
+	if tok, lit := p.scanIgnoreWhitespace(); tok != KEY {
+		return nil, fmt.Errorf("found %q, expected KEY", lit)
+	}
+	return p.scanKeyDefinition()
+}
+
+// scanKeyDefinition scans [name] [USING type] (key_part, ...) [options]
+// following INDEX or KEY
+func (p *Parser) scanKeyDefinition() (*Index, error) {
+	var index = &Index{Kind: KeyIndex}
+	// parse index, an unnamed index is named by Table.addIndex
+	if tok, lit := p.scanIgnoreWhitespace(); tok == IDENT {
+		index.Name = lit
+	} else {
 		p.unscan()
-		tok, lit = p.scanParenIdent()
-		if tok != IDENT {
-			return "", "", fmt.Errorf("found %q, expected ", lit)
+	}
+	if tok, _ := p.scanIgnoreWhitespace(); tok == USING {
+		if err := p.scanUsing(index); err != nil {
+			return nil, err
 		}
-		column = lit
 	} else {
-		return "", "", fmt.Errorf("found %q, expected ident", lit)
+		p.unscan()
+	}
+	// parse columns
+	parts, err := p.scanKeyParts()
+	if err != nil {
+		return nil, err
 	}
-	return index, column, nil
+	index.Parts = parts
+	if err = p.scanIndexOptions(index); err != nil {
+		return nil, err
+	}
+	return index, nil
 }
 
-func (p *Parser) scanConstraint() (*Constraint, error) {
-	var constraint = &Constraint{}
+// scanUsing scans the BTREE or HASH index type following USING, which
+// may come before or after the key parts
+func (p *Parser) scanUsing(index *Index) error {
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok != IDENT || !strings.EqualFold(lit, "BTREE") && !strings.EqualFold(lit, "HASH") {
+		return fmt.Errorf("found %q, expected BTREE or HASH", lit)
+	}
+	index.Using = strings.ToUpper(lit)
+	return nil
+}
+
+// scanIndexOptions scans the options following the key parts up to the
+// next comma or the closing paren of the table definition
+func (p *Parser) scanIndexOptions(index *Index) error {
+	options := make(map[string]string)
+	index.Options = options
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		switch tok {
+		case IDENT: // KEY_BLOCK_SIZE [=] value, VISIBLE, INVISIBLE
+			key := strings.ToUpper(lit)
+			tok, lit = p.scanIgnoreWhitespace()
+			if tok == EQUAL {
+				tok, lit = p.scanIgnoreWhitespace()
+			}
+			switch tok {
+			case SIZE, IDENT, STRING:
+				options[key] = lit
+			default:
+				p.unscan()
+				options[key] = ""
+			}
+		case USING:
+			if err := p.scanUsing(index); err != nil {
+				return err
+			}
+		case WITH:
+			tok1, lit1 := p.scanIgnoreWhitespace()
+			tok2, lit2 := p.scanIgnoreWhitespace()
+			if tok1 != PARSER || tok2 != IDENT {
+				return fmt.Errorf("found %q, expected WITH PARSER parser_name", lit1+lit2)
+			}
+			options["WITH PARSER"] = lit2
+		case COMMENT:
+			tok1, lit1 := p.scanIgnoreWhitespace()
+			if tok1 != STRING {
+				return fmt.Errorf("found %q, expected 'comment'", lit1)
+			}
+			options["COMMENT"] = lit1
+		case COMMA, CLOSE_PAREN, SEMI_COLON, EOF: // SEMI_COLON and EOF end ALTER TABLE ADD INDEX
+			p.unscan()
+			return nil
+		default:
+			return fmt.Errorf("found %q, expected index option", lit)
+		}
+	}
+}
+
+// scanConstraintName scans CONSTRAINT [symbol]
+func (p *Parser) scanConstraintName() (string, error) {
 	tok, lit := p.scanIgnoreWhitespace()
 	if tok != CONSTRAINT {
-		return nil, fmt.Errorf("found %q, expected CONSTRAINT", lit)
+		return "", fmt.Errorf("found %q, expected CONSTRAINT", lit)
 	}
-	tok, lit = p.scanIdent()
-	if tok != IDENT {
-		return nil, fmt.Errorf("found %q, expected ident", lit)
+	if tok, lit = p.scanIgnoreWhitespace(); tok != IDENT {
+		p.unscan()
+		return "", nil
 	}
-	constraint.Index = lit
+	return lit, nil
+}
+
+// scanCheck scans (expr) [[NOT] ENFORCED] following CHECK
+func (p *Parser) scanCheck() (*Check, error) {
+	var check = &Check{Enforced: true}
+	if tok, lit := p.scanIgnoreWhitespace(); tok != OPEN_PAREN {
+		return nil, fmt.Errorf("found %q, expected (", lit)
+	}
+	expr, err := p.scanExpr()
+	if err != nil {
+		return nil, err
+	}
+	check.Expr = expr
+	switch tok, _ := p.scanIgnoreWhitespace(); tok {
+	case ENFORCED:
+	case NOT:
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != ENFORCED {
+			return nil, fmt.Errorf("found %q, expected NOT ENFORCED", lit1)
+		}
+		check.Enforced = false
+	default:
+		p.unscan()
+	}
+	return check, nil
+}
+
+// scanConstraint scans FOREIGN KEY (column, ...) followed by the reference
+// definition, which must end the constraint
+func (p *Parser) scanConstraint() (*Constraint, error) {
+	var constraint = &Constraint{}
 	tok1, lit1 := p.scanIgnoreWhitespace()
 	tok2, lit2 := p.scanIgnoreWhitespace()
 	if tok1 != FOREIGN || tok2 != KEY {
 		return nil, fmt.Errorf("found %q, expected FOREIGN KEY", lit1+lit2)
 	}
-	tok, lit = p.scanParenIdent()
-	if tok != IDENT {
-		return nil, fmt.Errorf("found %q, expected ident", lit)
+	columns, err := p.scanIdentList()
+	if err != nil {
+		return nil, err
 	}
-	constraint.ForeignKey = lit
-	tok, lit = p.scanIgnoreWhitespace()
-	if tok != REFERENCES {
+	constraint.ForeignKey = columns
+	if tok, lit := p.scanIgnoreWhitespace(); tok != REFERENCES {
 		return nil, fmt.Errorf("found %q, expected REFERENCES", lit)
 	}
-	tok, lit = p.scanIdent()
-	if tok != IDENT {
-		return nil, fmt.Errorf("found %q, expected `table_name`", lit)
+	if err = p.scanReferences(constraint); err != nil {
+		return nil, err
 	}
-	constraint.TableName = lit
-	tok, lit = p.scanParenIdent()
-	if tok != IDENT {
-		return nil, fmt.Errorf("found %q, expected (`column_name`)", lit)
+	switch tok, lit := p.scanIgnoreWhitespace(); tok {
+	case COMMA, CLOSE_PAREN, SEMI_COLON, EOF: // ; and EOF end ALTER TABLE ADD
+		p.unscan()
+		return constraint, nil
+	default:
+		return nil, fmt.Errorf("found %q, expected ON DELETE, ON UPDATE, , or )", lit)
+	}
+}
+
+// scanReferences scans table (column, ...) following REFERENCES, then ON
+// DELETE and ON UPDATE actions in either order, into constraint whose
+// ForeignKey must be set already
+func (p *Parser) scanReferences(constraint *Constraint) error {
+	db, name, err := p.scanQualifiedIdent()
+	if err != nil {
+		return err
+	}
+	constraint.ReferencedDatabase, constraint.TableName = db, name
+	columns, err := p.scanIdentList()
+	if err != nil {
+		return err
+	}
+	if len(columns) != len(constraint.ForeignKey) {
+		return fmt.Errorf("foreign key has %d columns but references %d", len(constraint.ForeignKey), len(columns))
+	}
+	constraint.ColumnName = columns
+	for {
+		if tok, _ := p.scanIgnoreWhitespace(); tok != ON {
+			p.unscan()
+			return nil
+		}
+		tok, lit := p.scanIgnoreWhitespace()
+		var action *string
+		switch tok {
+		case DELETE:
+			action = &constraint.OnDelete
+		case UPDATE:
+			action = &constraint.OnUpdate
+		default:
+			return fmt.Errorf("found %q, expected ON DELETE or ON UPDATE", lit)
+		}
+		if *action != "" {
+			return fmt.Errorf("duplicate ON %s", strings.ToUpper(lit))
+		}
+		if *action, err = p.scanReferenceOption(); err != nil {
+			return err
+		}
+	}
+}
+
+// scanReferenceOption scans RESTRICT | CASCADE | SET NULL | SET DEFAULT | NO ACTION
+func (p *Parser) scanReferenceOption() (string, error) {
+	switch tok, lit := p.scanIgnoreWhitespace(); tok {
+	case RESTRICT, CASCADE:
+		return strings.ToUpper(lit), nil
+	case SET:
+		tok1, lit1 := p.scanIgnoreWhitespace()
+		if tok1 != NULL && tok1 != DEFAULT {
+			return "", fmt.Errorf("found %q, expected SET NULL or SET DEFAULT", lit1)
+		}
+		return "SET " + strings.ToUpper(lit1), nil
+	case NO:
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != ACTION {
+			return "", fmt.Errorf("found %q, expected NO ACTION", lit1)
+		}
+		return "NO ACTION", nil
+	default:
+		return "", fmt.Errorf("found %q, expected reference option", lit)
 	}
-	constraint.ColumnName = lit
-	return constraint, nil
 }
 
+// scanKV scans one table option key=value. DEFAULT is only ever a prefix
+// of CHARSET, CHARACTER SET or COLLATE, CHARACTER SET is returned as
+// CHARSET.
 func (p *Parser) scanKV() (string, string, error) {
 	tok, lit := p.scanIgnoreWhitespace()
+	prefixed := tok == DEFAULT
+	if prefixed {
+		tok, lit = p.scanIgnoreWhitespace()
+	}
+	key := lit
+	switch {
+	case tok == CHARACTER:
+		if tok1, lit1 := p.scanIgnoreWhitespace(); tok1 != SET {
+			return "", "", fmt.Errorf("found CHARACTER %q, expected CHARACTER SET", lit1)
+		}
+		key = "CHARSET"
+	case tok == IDENT && (strings.EqualFold(lit, "CHARSET") || strings.EqualFold(lit, "COLLATE")):
+	case prefixed:
+		return "", "", fmt.Errorf("found DEFAULT %q, expected CHARSET, CHARACTER SET or COLLATE", lit)
+	case tok != IDENT && tok != AUTO_INCREMENT && tok != COMMENT:
+		return "", "", fmt.Errorf("found %q, expected table option", lit)
+	}
 	tok1, lit1 := p.scanIgnoreWhitespace()
 	tok2, lit2 := p.scanIgnoreWhitespace()
-	if (tok != IDENT && tok != AUTO_INCREMENT) || tok1 != EQUAL || (tok2 != IDENT && tok2 != STRING && tok2 != SIZE) {
-		return "", "", fmt.Errorf("found %q, expected key=value", lit+lit1+lit2)
+	if tok1 != EQUAL || (tok2 != IDENT && tok2 != STRING && tok2 != SIZE && tok2 != DEFAULT) {
+		return "", "", fmt.Errorf("found %q, expected key=value", key+lit1+lit2)
 	}
-	return lit, lit2, nil
+	return key, lit2, nil
 }
 
 func (p *Parser) scanExtra() (map[string]string, error) {
 	extras := make(map[string]string)
 	for {
-		if tok, _ := p.scanIgnoreWhitespace(); tok != SEMI_COLON {
-			if tok != DEFAULT {
-				p.unscan()
+		tok, _ := p.scanIgnoreWhitespace()
+		p.unscan()
+		if tok == SEMI_COLON || tok == EOF {
+			return extras, nil
+		}
+		k, v, err := p.scanKV()
+		if err != nil {
+			return nil, err
+		}
+		extras[k] = v
+	}
+}
+
+// scanInsert scans the rest of INSERT [INTO] table [(columns)] VALUES
+// (...), (...) and returns the table name and the number of value tuples.
+// Only the top level tuples after VALUES count, and counting stops at ON
+// DUPLICATE KEY UPDATE whose VALUES(column) is a function.
+func (p *Parser) scanInsert() (string, int, error) {
+	if tok, _ := p.scanIgnoreWhitespace(); tok != INTO {
+		p.unscan()
+	}
+	_, name, err := p.scanQualifiedIdent()
+	if err != nil {
+		return "", 0, err
+	}
+	var rows, depth int
+	var values, row bool // row: a tuple may start here, after VALUES, a comma or ROW
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		switch {
+		case tok == SEMI_COLON || tok == EOF:
+			return name, rows, nil
+		case tok == OPEN_PAREN:
+			if row && depth == 0 {
+				rows++
 			}
-			k, v, err := p.scanKV()
-			if err != nil {
-				return nil, err
+			row = false
+			depth++
+		case tok == CLOSE_PAREN:
+			depth--
+		case depth > 0:
+		case tok == VALUES:
+			row, values = !values, true
+		case tok == COMMA:
+			row = values
+		case tok == ON:
+			p.skipStatement()
+			return name, rows, nil
+		case tok == IDENT && strings.EqualFold(lit, "ROW"):
+		default:
+			row = false
+		}
+	}
+}
+
+// scanDelimiter scans the new delimiter of a DELIMITER command, which
+// runs up to the end of the line, and ends statements on it from then on
+func (p *Parser) scanDelimiter() error {
+	var delimiter string
+	for {
+		tok, lit := p.scan()
+		if tok == EOF || (tok == WS && (delimiter != "" || Newlines(lit) > 0)) {
+			break
+		} else if tok != WS {
+			delimiter += lit
+		}
+	}
+	if delimiter == "" {
+		return fmt.Errorf("found end of line, expected delimiter")
+	}
+	p.s.Delimiter = delimiter
+	return nil
+}
+
+// scanDroppedTables records the tables of TABLE [IF EXISTS] a, b following
+// DROP, it stops at the first unexpected token
+func (p *Parser) scanDroppedTables() {
+	if tok, _ := p.scanIgnoreWhitespace(); tok != TABLE {
+		p.unscan()
+		return
+	}
+	if tok, _ := p.scanIgnoreWhitespace(); tok == IF {
+		if tok, _ = p.scanIgnoreWhitespace(); tok != EXISTS {
+			p.unscan()
+			return
+		}
+	} else {
+		p.unscan()
+	}
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		switch tok {
+		case IDENT:
+			if tok1, _ := p.scan(); tok1 != DOT {
+				p.unscan()
+			} else if tok2, lit2 := p.scan(); tok2 == IDENT {
+				lit = lit2
+			} else {
+				p.unscan()
+				return
 			}
-			extras[k] = v
-		} else {
+			p.dropped = append(p.dropped, lit)
+		case COMMA:
+		default:
 			p.unscan()
-			break
+			return
 		}
 	}
-	return extras, nil
 }
 
 // parse one table
 func (p *Parser) parse() (*Table, error) {
-	table := &Table{
-		Columns:     make(map[string]*Column),
-		UniqueKeys:  make(map[string]string),
-		Keys:        make(map[string]string),
-		Constraints: make(map[string]*Constraint),
-		Extras:      make(map[string]string),
-	}
 	for {
-		if tok, lit := p.scanIgnoreWhitespace(); tok == DROP || tok == LOCK || tok == UNLOCK || tok == ANNOTATION {
-			for { // ignore drop, lock and unlock statement
-				if tok, _ := p.scanIgnoreWhitespace(); tok == SEMI_COLON {
-					break
-				} else if tok == EOF {
-					return nil, nil
-				}
+		tok, lit := p.scanIgnoreWhitespace()
+		if tok == CREATE {
+			if tok1, _ := p.scanIgnoreWhitespace(); tok1 == TABLE {
+				break
 			}
-		} else if tok == SEMI_COLON || tok == ANNOTATION {
-			continue
-		} else if tok == CREATE {
-			break
-		} else if tok == EOF {
+			p.unscan() // skipped below, e.g. CREATE DEFINER=... VIEW
+		}
+		switch tok {
+		case EOF:
 			return nil, nil
-		} else {
-			return nil, fmt.Errorf("unexpected %v: %q", tok, lit)
+		case SEMI_COLON, ANNOTATION:
+			continue
+		case INSERT:
+			name, rows, err := p.scanInsert()
+			if err != nil {
+				return nil, err
+			}
+			if p.rowCounts == nil {
+				p.rowCounts = make(map[string]int)
+			}
+			p.rowCounts[name] += rows
+		default: // ignore other statements, e.g. DROP, LOCK, SET, GRANT or CREATE VIEW
+			if tok == IDENT && strings.EqualFold(lit, "DELIMITER") {
+				if err := p.scanDelimiter(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			p.skipped = append(p.skipped, strings.ToUpper(lit))
+			if tok == DROP {
+				p.scanDroppedTables()
+			}
+			if !p.skipStatement() {
+				return nil, nil
+			}
 		}
 	}
-	if tok, lit := p.scanIgnoreWhitespace(); tok != TABLE {
-		return nil, fmt.Errorf("found CREATE %q, expected CREATE TABLE", lit)
+	return p.scanCreateTable()
+}
+
+// skipStatement skips the rest of the statement up to and including its
+// semicolon, it reports false when the input ends first
+func (p *Parser) skipStatement() bool {
+	for {
+		if tok, _ := p.scanIgnoreWhitespace(); tok == SEMI_COLON {
+			return true
+		} else if tok == EOF {
+			return false
+		}
 	}
+}
 
+// scanCreateTable scans the table name and definition following CREATE TABLE
+func (p *Parser) scanCreateTable() (*Table, error) {
+	table := &Table{
+		Columns:      make(map[string]*Column),
+		UniqueKeys:   make(map[string][]string),
+		FulltextKeys: make(map[string][]string),
+		SpatialKeys:  make(map[string][]string),
+		Keys:         make(map[string][]string),
+		Indexes:      make(map[string]*Index),
+		Constraints:  make(map[string]*Constraint),
+		Checks:       make(map[string]*Check),
+		Extras:       make(map[string]string),
+	}
 	// scan table name
-	if tok, lit := p.scanIdent(); tok == IDENT {
-		table.Name = lit
-	} else {
-		return nil, fmt.Errorf("found CREATE TABLE %d %q, expected CREATE TABLE `ident`", tok, lit)
+	db, name, err := p.scanQualifiedIdent()
+	if err != nil {
+		return nil, err
 	}
+	table.Database, table.Name = db, name
+	p.lintIdent(table.Name, "")
 
 	// scan columns
 	if tok, lit := p.scanIgnoreWhitespace(); tok != OPEN_PAREN {
@@ -378,47 +1252,67 @@ func (p *Parser) parse() (*Table, error) {
 		switch tok {
 		case IDENT:
 			p.unscan()
-			col, err := p.scanColumn()
+			col, err := p.scanColumn(table)
 			if err != nil {
 				return nil, err
 			}
-			table.Columns[col.Name] = col
-		case PRIMARY:
-			p.unscan()
-			key, err := p.scanPrimaryKey()
+			table.addColumn(col)
+		case PRIMARY, UNIQUE, FULLTEXT, SPATIAL, KEY:
+			index, err := p.scanIndex(tok)
 			if err != nil {
 				return nil, err
 			}
-			table.PrimaryKey = key
-		case UNIQUE:
-			k, v, err := p.scanKey()
-			if err != nil {
+			// an inline unique key is named after its column, it gives way
+			// to an explicitly named key the way MySQL names implicit keys
+			if old := table.Indexes[index.Name]; index.Name != "" && old != nil && old.Inline && old.Kind == UniqueIndex {
+				table.renameIndex(old, table.indexName(old.Name))
+			}
+			if err = table.addNewIndex(index); err != nil {
 				return nil, err
 			}
-			table.UniqueKeys[k] = v
-		case KEY:
+		case CONSTRAINT:
 			p.unscan()
-			index, col, err := p.scanKey()
+			name, err := p.scanConstraintName()
 			if err != nil {
 				return nil, err
 			}
-			table.Keys[index] = col
-		case CONSTRAINT:
-			p.unscan()
-			cos, err := p.scanConstraint()
+			if tok, _ = p.scanIgnoreWhitespace(); tok == CHECK {
+				check, err := p.scanCheck()
+				if err != nil {
+					return nil, err
+				}
+				check.Name = name
+				table.addCheck(check)
+			} else {
+				p.unscan()
+				cos, err := p.scanConstraint()
+				if err != nil {
+					return nil, err
+				}
+				cos.Index = name
+				table.addConstraint(cos)
+			}
+		case CHECK:
+			check, err := p.scanCheck()
 			if err != nil {
 				return nil, err
 			}
-			table.Constraints[cos.ForeignKey] = cos
+			table.addCheck(check)
 		case CLOSE_PAREN:
 			tok, lit = p.scanIgnoreWhitespace()
-			if tok != SEMI_COLON {
+			if tok != SEMI_COLON && tok != EOF {
 				p.unscan()
 				extras, err := p.scanExtra()
 				if err != nil {
 					return nil, err
 				}
 				table.Extras = extras
+				for k, v := range extras {
+					if strings.EqualFold(k, "CHARSET") {
+						table.Charset = v
+					}
+				}
+				p.scanIgnoreWhitespace() // consume the terminating semicolon
 			}
 			return table, nil
 		case COMMA:
@@ -431,18 +1325,95 @@ func (p *Parser) parse() (*Table, error) {
 	}
 }
 
+// ParseError is a syntax error located at the token it was found at
+type ParseError struct {
+	Line int // 1-based
+	Col  int // 1-based, in runes
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// errorAt locates err at the last scanned token
+func (p *Parser) errorAt(err error) error {
+	return &ParseError{Line: p.buf.line, Col: p.buf.col, Msg: err.Error()}
+}
+
+// ParseNext parses the next table, it returns nil table when input is exhausted
+func (p *Parser) ParseNext() (*Table, error) {
+	table, err := p.parse()
+	if serr := p.s.Err(); serr != nil {
+		err = serr
+	}
+	if err != nil {
+		return nil, p.errorAt(err)
+	}
+	if table != nil {
+		p.lintDeprecated(table)
+		if p.Strict {
+			err = table.Validate()
+		}
+	}
+	return table, err
+}
+
+// Offset returns the byte offset right after the last parsed statement,
+// which can be used to resume scanning from where the last table ended
+func (p *Parser) Offset() int {
+	if p.buf.n != 0 {
+		return p.buf.off
+	}
+	return p.s.Offset()
+}
+
+// Warnings returns the warnings collected so far by a linting parser
+func (p *Parser) Warnings() []Warning {
+	return p.warnings
+}
+
+// ParseResult holds everything a dump contains besides the tables
+type ParseResult struct {
+	Schema   Schema
+	Dropped  []string  // tables dropped by DROP TABLE, in dump order
+	Skipped  []string  // leading keyword of every skipped statement, e.g. DROP or GRANT
+	Warnings []Warning // see Parser.Lint
+}
+
 // Parse returns parsed table schema and an error
 func (p *Parser) Parse() (Schema, error) {
+	result, err := p.ParseAll()
+	return result.Schema, err
+}
+
+// ParseString parses the tables of the dump held in sql
+func ParseString(sql string) (Schema, error) {
+	return NewParser(strings.NewReader(sql)).Parse()
+}
+
+// ParseAll parses the whole dump like Parse and also reports the
+// statements skipped along the way
+func (p *Parser) ParseAll() (*ParseResult, error) {
 	schema := make(Schema)
+	result := &ParseResult{Schema: schema}
 	for {
-		table, err := p.parse()
+		table, err := p.ParseNext()
 		if err != nil {
-			return schema, err // return already parsed tables and error
+			return result, err // return already parsed tables and error
 		}
 		if table == nil { // parse done
 			break
 		}
 		schema[table.Name] = table
 	}
-	return schema, nil
+	for name, rows := range p.rowCounts {
+		if table, ok := schema[name]; ok {
+			table.RowCount = rows
+		}
+	}
+	result.Dropped = p.dropped
+	result.Skipped = p.skipped
+	result.Warnings = p.warnings
+	return result, nil
 }
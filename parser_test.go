@@ -1,12 +1,13 @@
 package sqlparser
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
 
 func TestParser(t *testing.T) {
-	sqlStmt := "--this is a comment\nDROP TABLE IF EXISTS `user`;\n/* this is another comment */;\nCREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `username` varchar(20) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;"
+	sqlStmt := "-- this is a comment\nDROP TABLE IF EXISTS `user`;\n/* this is another comment */;\nCREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `username` varchar(20) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;"
 	expected := make(Schema)
 	columns := make(map[string]*Column)
 	columns["id"] = &Column{
@@ -58,3 +59,1247 @@ func TestParser(t *testing.T) {
 	// }
 	// fmt.Printf("%v\n", schema)
 }
+
+func TestParserTypeCase(t *testing.T) {
+	tests := []struct {
+		types       []string
+		typ         string
+		size, scale int
+		hasSize     bool
+	}{
+		{[]string{"bigint(20)", "BIGINT(20)", "BigInt(20)"}, "bigint", 20, 0, true},
+		{[]string{"varchar(255)", "VARCHAR(255)", "VarChar (255)"}, "varchar", 255, 0, true},
+		{[]string{"decimal(10,2)", "DECIMAL(10, 2)", "Decimal( 10 ,2 )"}, "decimal", 10, 2, true},
+		{[]string{"datetime", "DATETIME", "DateTime"}, "datetime", 0, 0, false},
+	}
+	for _, test := range tests {
+		for _, typ := range test.types {
+			schema, err := ParseString("CREATE TABLE `t` (\n  `c` " + typ + " NOT NULL\n);")
+			if err != nil {
+				t.Fatalf("%s: %v", typ, err)
+			}
+			c := schema["t"].Columns["c"]
+			if c.Type != test.typ || c.Size != test.size || c.Scale != test.scale || c.HasSize != test.hasSize {
+				t.Errorf("%s: expected %s size %d scale %d, found %s size %d scale %d", typ, test.typ, test.size, test.scale, c.Type, c.Size, c.Scale)
+			}
+		}
+	}
+
+	schema, err := ParseString("CREATE TABLE `t` (\n  `a` ENUM('x','Y'),\n  `b` Set('x')\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := schema["t"].Columns["a"]; a.Type != "enum" || strings.Join(a.EnumValues, ",") != "x,Y" {
+		t.Errorf("expected enum x,Y, found %s %v", a.Type, a.EnumValues)
+	}
+	if b := schema["t"].Columns["b"]; b.Type != "set" || strings.Join(b.SetValues, ",") != "x" {
+		t.Errorf("expected set x, found %s %v", b.Type, b.SetValues)
+	}
+}
+
+func TestParserColumnCharset(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `a` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL,\n" +
+		"  `b` varchar(20) COLLATE latin1_bin charset latin1 DEFAULT '',\n  `c` text COLLATE utf8mb4_bin,\n  `d` varchar(20)\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		column, charset, collation string
+	}{
+		{"a", "utf8mb4", "utf8mb4_unicode_ci"},
+		{"b", "latin1", "latin1_bin"}, // either order
+		{"c", "", "utf8mb4_bin"},
+		{"d", "", ""},
+	}
+	user := schema["user"]
+	for _, test := range tests {
+		c := user.Columns[test.column]
+		if c.Charset != test.charset || c.Collation != test.collation {
+			t.Errorf("column %s: expected %q %q, found %q %q", test.column, test.charset, test.collation, c.Charset, c.Collation)
+		}
+	}
+	if sql := user.Columns["b"].SQL(); sql != "`b` varchar(20) CHARACTER SET latin1 COLLATE latin1_bin DEFAULT ''" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	if _, err := ParseString("CREATE TABLE `user` (\n  `a` varchar(20) CHARACTER utf8mb4\n);"); err == nil {
+		t.Errorf("expected error for CHARACTER without SET")
+	}
+}
+
+func TestParserFulltextAndSpatialKeys(t *testing.T) {
+	sqlStmt := "CREATE TABLE `place` (\n  `id` int,\n  `title` varchar(100),\n  `body` text,\n  `geom` geometry NOT NULL,\n" +
+		"  KEY `idx_title` (`title`),\n  FULLTEXT KEY `ft_body` (`title`, `body`) WITH PARSER ngram,\n  SPATIAL INDEX `sp_geom` (`geom`)\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	place := schema["place"]
+	if keys := strings.Join(place.FulltextKeys["ft_body"], ","); keys != "title,body" || len(place.FulltextKeys) != 1 {
+		t.Errorf("expected fulltext key ft_body on title,body, found %v", place.FulltextKeys)
+	}
+	if keys := strings.Join(place.SpatialKeys["sp_geom"], ","); keys != "geom" || len(place.SpatialKeys) != 1 {
+		t.Errorf("expected spatial key sp_geom on geom, found %v", place.SpatialKeys)
+	}
+	if len(place.Keys) != 1 || place.Keys["idx_title"] == nil {
+		t.Errorf("expected only idx_title in keys, found %v", place.Keys)
+	}
+	if index := place.Indexes["sp_geom"]; index.Kind != SpatialIndex || index.SQL() != "SPATIAL KEY `sp_geom` (`geom`)" {
+		t.Errorf("unexpected spatial index %+v", index)
+	}
+	if pairs := place.RedundantIndexes(); len(pairs) != 0 {
+		t.Errorf("expected no redundant indexes, found %v", pairs)
+	}
+	if err := place.RemoveColumn("geom"); err != nil {
+		t.Fatal(err)
+	}
+	if len(place.SpatialKeys) != 0 || place.Indexes["sp_geom"] != nil {
+		t.Errorf("expected sp_geom to be removed with its column, found %v", place.SpatialKeys)
+	}
+}
+
+func TestParserKeyPrefixLength(t *testing.T) {
+	sqlStmt := "CREATE TABLE `post` (\n  `id` int,\n  `name` varchar(255),\n  `body` text,\n" +
+		"  KEY `idx_name` (`name`(10)),\n  KEY `idx_id_body` (`id`, `body` (100))\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := schema["post"]
+	if parts := post.Indexes["idx_name"].Parts; len(parts) != 1 || parts[0] != (KeyPart{Column: "name", Length: 10}) {
+		t.Errorf("expected name(10), found %+v", parts)
+	}
+	if parts := post.Indexes["idx_id_body"].Parts; len(parts) != 2 || parts[0] != (KeyPart{Column: "id"}) || parts[1] != (KeyPart{Column: "body", Length: 100}) {
+		t.Errorf("expected id, body(100), found %+v", parts)
+	}
+	if sql := post.Indexes["idx_id_body"].SQL(); sql != "KEY `idx_id_body` (`id`,`body`(100))" {
+		t.Errorf("unexpected index SQL %s", sql)
+	}
+
+	sqlStmt = "CREATE TABLE `post` (\n  `name` varchar(255),\n  KEY `idx_name` (`name`(0))\n);"
+	if _, err := ParseString(sqlStmt); err == nil || !strings.Contains(err.Error(), "length cannot be 0") {
+		t.Errorf("expected error for zero prefix length, found %v", err)
+	}
+}
+
+func TestParserColumnCollateOnly(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `code` varchar(20) COLLATE utf8mb4_bin NOT NULL,\n  `tag` char(4) COLLATE 'ascii_general_ci'\n) DEFAULT CHARSET=latin1;"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	code := user.Columns["code"]
+	if code.Charset != "" || code.Collation != "utf8mb4_bin" || code.Nullable {
+		t.Errorf("expected NOT NULL column with collation utf8mb4_bin only, found %+v", code)
+	}
+	if cs := code.EffectiveCharset(user); cs != "utf8mb4" {
+		t.Errorf("expected charset utf8mb4 inferred from the collation, found %q", cs)
+	}
+	if sql := code.SQL(); sql != "`code` varchar(20) COLLATE utf8mb4_bin NOT NULL" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	if tag := user.Columns["tag"]; tag.Collation != "ascii_general_ci" || tag.EffectiveCharset(user) != "ascii" {
+		t.Errorf("expected quoted collation ascii_general_ci, found %q", tag.Collation)
+	}
+}
+
+func TestParserFunctionalKey(t *testing.T) {
+	sqlStmt := "CREATE TABLE `doc` (\n  `id` bigint(20) NOT NULL,\n  `data` longtext,\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `idx_data` ((CAST(data AS CHAR(10))))\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := schema["doc"]
+	index := doc.Indexes["idx_data"]
+	if index == nil {
+		t.Fatalf("expected index idx_data, but not found")
+	}
+	if index.Kind != UniqueIndex {
+		t.Errorf("expected unique index, found %v", index.Kind)
+	}
+	if len(index.Parts) != 1 || index.Parts[0].Column != "" {
+		t.Fatalf("expected one expression part, found %v", index.Parts)
+	}
+	if expr := index.Parts[0].Expr; expr != "CAST(data AS CHAR(10))" {
+		t.Errorf("expected expression CAST(data AS CHAR(10)), found %q", expr)
+	}
+	if keys := doc.UniqueKeys["idx_data"]; len(keys) != 1 || keys[0] != "(CAST(data AS CHAR(10)))" {
+		t.Errorf("expected unique key (CAST(data AS CHAR(10))), found %q", keys)
+	}
+	if doc.PrimaryKey != "id" || doc.Indexes["PRIMARY"].Kind != PrimaryIndex {
+		t.Errorf("expected primary key id, found %q", doc.PrimaryKey)
+	}
+}
+
+func TestParserStrictNotNullDefaultNull(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `username` varchar(20) NOT NULL DEFAULT NULL\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["user"].Columns["username"].Nullable {
+		t.Errorf("expected username to be NOT NULL")
+	}
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.Strict = true
+	if _, err := p.Parse(); err == nil {
+		t.Errorf("expected error for NOT NULL DEFAULT NULL in strict mode")
+	}
+}
+
+func TestParserNullableDefault(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `t` (\n  `a` int\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := schema["t"].Columns["a"]; !a.Nullable || a.RequiredOnInsert() {
+		t.Errorf("expected a column without NULL or NOT NULL to be nullable, found %+v", a)
+	}
+}
+
+func TestParserColumnAutoIncrementValue(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT=5 COMMENT 'id',\n  PRIMARY KEY (`id`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := schema["user"].Columns["id"]
+	if !id.AutoIncr || id.Comment != "id" {
+		t.Errorf("expected auto increment column with comment, found %+v", id)
+	}
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.Strict = true
+	if _, err := p.Parse(); err == nil {
+		t.Errorf("expected error for AUTO_INCREMENT=5 on a column in strict mode")
+	}
+}
+
+func TestParserQualifiedTableName(t *testing.T) {
+	sqlStmt := "CREATE TABLE `mydb`.`users` (\n  `id` bigint(20) NOT NULL\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := schema["users"]
+	if users == nil {
+		t.Fatalf("expected table users, but not found")
+	}
+	if users.Database != "mydb" || users.Name != "users" {
+		t.Errorf("expected mydb.users, found %s.%s", users.Database, users.Name)
+	}
+}
+
+func TestParserOffset(t *testing.T) {
+	sqlStmt := "CREATE TABLE `a` (\n  `id` int\n) ENGINE=InnoDB;\nCREATE TABLE `b` (\n  `id` int\n);\nCREATE TABLE `c` (\n  `id` int\n) ENGINE=InnoDB DEFAULT CHARSET=utf8;\n"
+	p := NewParser(strings.NewReader(sqlStmt))
+	var offsets []int
+	for {
+		table, err := p.ParseNext()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if table == nil {
+			break
+		}
+		offsets = append(offsets, p.Offset())
+	}
+	if len(offsets) != 3 {
+		t.Fatalf("expected 3 tables, found %d", len(offsets))
+	}
+	if first := strings.Index(sqlStmt, ";") + 1; offsets[0] != first {
+		t.Errorf("expected first offset %d, found %d", first, offsets[0])
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] <= offsets[i-1] {
+			t.Errorf("expected offsets to increase, found %v", offsets)
+		}
+	}
+	if last := strings.LastIndex(sqlStmt, ";") + 1; offsets[2] != last {
+		t.Errorf("expected last offset %d, found %d", last, offsets[2])
+	}
+}
+
+func TestParserMultiLineComment(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL COMMENT 'first line\nsecond line'\n) ENGINE=InnoDB COMMENT='table\ncomment';"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if c := user.Columns["id"].Comment; c != "first line\nsecond line" {
+		t.Errorf("expected multi-line column comment, found %q", c)
+	}
+	if c := user.Extras["COMMENT"]; c != "table\ncomment" {
+		t.Errorf("expected multi-line table comment, found %q", c)
+	}
+}
+
+func TestParserTruncatedDefault(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `username` varchar(20) DEFAULT "
+	_, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if pe, ok := err.(*ParseError); !ok || pe.Msg != "unexpected EOF after DEFAULT" {
+		t.Errorf("expected unexpected EOF after DEFAULT, found %v", err)
+	}
+}
+
+func TestParserErrorPosition(t *testing.T) {
+	sqlStmt := "DROP TABLE IF EXISTS `user`;\nCREATE TABLE `user` (\n  `id` int,\n  `name` varchar(20) NOT nil\n);"
+	_, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, found %T %v", err, err)
+	}
+	if pe.Line != 4 || pe.Col != 26 {
+		t.Errorf("expected error at line 4, col 26, found line %d, col %d", pe.Line, pe.Col)
+	}
+	if expected := `line 4, col 26: found "nil", expected NULL`; err.Error() != expected {
+		t.Errorf("expected %q, found %q", expected, err.Error())
+	}
+}
+
+func TestParserCheck(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `age` int CHECK (`age` >= 0),\n  `score` int,\n" +
+		"  CONSTRAINT `chk_score` CHECK (score < 100) NOT ENFORCED,\n  CHECK (age < 200) ENFORCED\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := schema["user"].Checks
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, found %d", len(checks))
+	}
+	expected := []Check{
+		{Name: "user_chk_1", Expr: "age >= 0", Enforced: true},
+		{Name: "chk_score", Expr: "score < 100", Enforced: false},
+		{Name: "user_chk_2", Expr: "age < 200", Enforced: true},
+	}
+	for _, e := range expected {
+		c := checks[e.Name]
+		if c == nil {
+			t.Errorf("expected check %s, but not found", e.Name)
+			continue
+		}
+		if *c != e {
+			t.Errorf("expected %+v, found %+v", e, *c)
+		}
+	}
+}
+
+func TestParserIndexOptions(t *testing.T) {
+	sqlStmt := "CREATE TABLE `post` (\n  `id` bigint(20) NOT NULL,\n  `body` longtext,\n  PRIMARY KEY (`id`) KEY_BLOCK_SIZE=4,\n" +
+		"  FULLTEXT KEY `ft_body` (`body`) WITH PARSER ngram COMMENT 'search'\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := schema["post"]
+	ft := post.Indexes["ft_body"]
+	if ft == nil {
+		t.Fatalf("expected index ft_body, but not found")
+	}
+	if ft.Kind != FulltextIndex {
+		t.Errorf("expected fulltext index, found %v", ft.Kind)
+	}
+	if ft.Parts[0].Column != "body" {
+		t.Errorf("expected column body, found %v", ft.Parts)
+	}
+	if ft.Options["WITH PARSER"] != "ngram" || ft.Options["COMMENT"] != "search" {
+		t.Errorf("expected WITH PARSER ngram and COMMENT search, found %v", ft.Options)
+	}
+	if size := post.Indexes["PRIMARY"].Options["KEY_BLOCK_SIZE"]; size != "4" {
+		t.Errorf("expected KEY_BLOCK_SIZE 4, found %q", size)
+	}
+	if _, ok := post.Keys["ft_body"]; ok {
+		t.Errorf("expected fulltext index not to be listed in Keys")
+	}
+}
+
+func TestParserTableAtEOF(t *testing.T) {
+	for _, sqlStmt := range []string{"CREATE TABLE t (`id` int)", "CREATE TABLE t (`id` int) ENGINE=InnoDB"} {
+		schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+		if err != nil {
+			t.Errorf("%q: %v", sqlStmt, err)
+			continue
+		}
+		if table := schema["t"]; table == nil || table.Columns["id"] == nil {
+			t.Errorf("%q: expected table t with column id", sqlStmt)
+		}
+	}
+}
+
+func TestParserCrossDatabaseForeignKey(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `city_id` bigint(20),\n  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `geo`.`city` (`id`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cos := schema["user"].Constraints["fk_city"]
+	if cos == nil {
+		t.Fatalf("expected constraint fk_city, but not found")
+	}
+	if cos.ReferencedDatabase != "geo" || cos.TableName != "city" || strings.Join(cos.ColumnName, ",") != "id" {
+		t.Errorf("expected reference geo.city(id), found %s.%s(%v)", cos.ReferencedDatabase, cos.TableName, cos.ColumnName)
+	}
+	if sql := cos.SQL(); sql != "CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `geo`.`city` (`id`)" {
+		t.Errorf("unexpected constraint SQL %s", sql)
+	}
+}
+
+func TestParserPrimaryKeyUsing(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  PRIMARY KEY (`id`) USING BTREE\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if user.PrimaryKey != "id" {
+		t.Errorf("expected primary key id, found %q", user.PrimaryKey)
+	}
+	if using := user.Indexes["PRIMARY"].Using; using != "BTREE" {
+		t.Errorf("expected USING BTREE, found %q", using)
+	}
+	if sql := user.Indexes["PRIMARY"].SQL(); sql != "PRIMARY KEY (`id`) USING BTREE" {
+		t.Errorf("unexpected primary key SQL %s", sql)
+	}
+}
+
+func TestParserIndexUsing(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `email` varchar(255),\n  `city_id` int,\n  `name` varchar(20),\n" +
+		"  PRIMARY KEY USING BTREE (`id`),\n  UNIQUE KEY `uk_email` (`email`) USING HASH,\n  KEY `idx_city` USING btree (`city_id`),\n  KEY `idx_name` (`name`)\n) ENGINE=MEMORY;"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"PRIMARY": "BTREE", "uk_email": "HASH", "idx_city": "BTREE", "idx_name": ""}
+	for name, using := range expected {
+		if index := schema["user"].Indexes[name]; index == nil || index.Using != using {
+			t.Errorf("expected %s USING %q, found %+v", name, using, index)
+		}
+	}
+	if sql := schema["user"].Indexes["idx_city"].SQL(); sql != "KEY `idx_city` (`city_id`) USING BTREE" {
+		t.Errorf("unexpected index SQL %s", sql)
+	}
+
+	sqlStmt = "CREATE TABLE `user` (\n  `id` bigint(20),\n  KEY `idx_id` (`id`) USING RTREE\n);"
+	if _, err := ParseString(sqlStmt); err == nil || !strings.Contains(err.Error(), "expected BTREE or HASH") {
+		t.Errorf("expected error for USING RTREE, found %v", err)
+	}
+}
+
+func TestParserDuplicateKeyName(t *testing.T) {
+	sqlStmt := "CREATE TABLE `t` (\n  `a` int UNIQUE,\n  `b` int,\n  KEY `a` (`b`)\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := schema["t"]
+	if index := table.Indexes["a"]; index == nil || index.Kind != KeyIndex {
+		t.Errorf("expected KEY a, found %+v", index)
+	}
+	if index := table.Indexes["a_2"]; index == nil || index.Kind != UniqueIndex || !index.Inline {
+		t.Errorf("expected inline unique key a_2, found %+v", index)
+	}
+	if keys := table.UniqueKeys["a_2"]; len(table.UniqueKeys) != 1 || strings.Join(keys, ",") != "a" {
+		t.Errorf("unexpected unique keys %v", table.UniqueKeys)
+	}
+	if sql := table.SQL(); !strings.Contains(sql, "UNIQUE") {
+		t.Errorf("expected the unique key in %s", sql)
+	}
+
+	sqlStmt = "CREATE TABLE `t` (\n  `a` int,\n  KEY `a` (`a`),\n  UNIQUE KEY `a` (`a`)\n);"
+	if _, err := ParseString(sqlStmt); err == nil || !strings.Contains(err.Error(), "duplicate key name") {
+		t.Errorf("expected duplicate key name error, found %v", err)
+	}
+}
+
+func TestParserPrimaryKeyPrefix(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `name` varchar(255) NOT NULL,\n  PRIMARY KEY (`name`(191))\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if user.PrimaryKey != "name" {
+		t.Errorf("expected primary key name, found %q", user.PrimaryKey)
+	}
+	if part := user.Indexes["PRIMARY"].Parts[0]; part.Column != "name" || part.Length != 191 {
+		t.Errorf("expected key part name(191), found %+v", part)
+	}
+	if sql := user.Indexes["PRIMARY"].SQL(); sql != "PRIMARY KEY (`name`(191))" {
+		t.Errorf("unexpected primary key SQL %s", sql)
+	}
+}
+
+func TestParserExpressionDefault(t *testing.T) {
+	sqlStmt := "CREATE TABLE `doc` (\n  `id` varchar(36) DEFAULT (uuid()),\n  `meta` longtext DEFAULT (json_object('k(', concat('a)', uuid())))\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := schema["doc"]
+	if d := doc.Columns["id"].Default; d != Expr("uuid()") {
+		t.Errorf("expected default expression uuid(), found %#v", d)
+	}
+	expected := Expr("json_object('k(', concat('a)', uuid()))")
+	if d := doc.Columns["meta"].Default; d != expected {
+		t.Errorf("expected default expression %s, found %#v", expected, d)
+	}
+	if sql := doc.Columns["meta"].SQL(); sql != "`meta` longtext DEFAULT ("+string(expected)+")" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+}
+
+func TestParserForeignKeyActions(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n" +
+		"  `a_id` bigint(20),\n" +
+		"  `b_id` bigint(20),\n" +
+		"  `c_id` bigint(20),\n" +
+		"  CONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`) ON DELETE CASCADE ON UPDATE SET NULL,\n" +
+		"  CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`) ON UPDATE NO ACTION ON DELETE RESTRICT,\n" +
+		"  CONSTRAINT `fk_c` FOREIGN KEY (`c_id`) REFERENCES `c` (`id`),\n" +
+		"  `d_id` bigint(20),\n" +
+		"  CONSTRAINT `fk_d` FOREIGN KEY (`d_id`) REFERENCES `d` (`id`) ON UPDATE CASCADE ON DELETE SET DEFAULT\n" +
+		");"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, onDelete, onUpdate string
+	}{
+		{"fk_a", "CASCADE", "SET NULL"},
+		{"fk_b", "RESTRICT", "NO ACTION"}, // reversed order
+		{"fk_c", "", ""},                  // omitted actions are left empty
+		{"fk_d", "SET DEFAULT", "CASCADE"},
+	}
+	for _, test := range tests {
+		cos := schema["user"].Constraints[test.name]
+		if cos == nil {
+			t.Fatalf("expected constraint %s, but not found", test.name)
+		}
+		if cos.OnDelete != test.onDelete || cos.OnUpdate != test.onUpdate {
+			t.Errorf("expected %s ON DELETE %q ON UPDATE %q, found %q %q", test.name, test.onDelete, test.onUpdate, cos.OnDelete, cos.OnUpdate)
+		}
+	}
+	if sql := schema["user"].Constraints["fk_b"].SQL(); sql != "CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`) ON DELETE RESTRICT ON UPDATE NO ACTION" {
+		t.Errorf("unexpected constraint SQL %s", sql)
+	}
+
+	sqlStmt = "CREATE TABLE `user` (\n  `a_id` bigint(20),\n  FOREIGN KEY (`a_id`) REFERENCES `a` (`id`) ON DELETE CASCADE MATCH FULL\n);"
+	if _, err := NewParser(strings.NewReader(sqlStmt)).Parse(); err == nil {
+		t.Errorf("expected error for trailing MATCH FULL")
+	}
+
+	sqlStmt = "CREATE TABLE `user` (\n  `a_id` bigint(20),\n  FOREIGN KEY (`a_id`) REFERENCES `a` (`id`) ON DELETE CASCADE ON DELETE RESTRICT\n);"
+	if _, err := NewParser(strings.NewReader(sqlStmt)).Parse(); err == nil {
+		t.Errorf("expected error for duplicate ON DELETE")
+	}
+}
+
+func TestParserCompositeForeignKey(t *testing.T) {
+	sqlStmt := "CREATE TABLE `item` (\n  `org_id` int,\n  `shop_id` int,\n" +
+		"  CONSTRAINT `fk_shop` FOREIGN KEY (`org_id`, `shop_id`) REFERENCES `shop` (`org_id`, `id`) ON DELETE CASCADE\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cos := schema["item"].Constraints["fk_shop"]
+	if cos == nil {
+		t.Fatalf("expected constraint fk_shop, but not found")
+	}
+	if fk, ref := strings.Join(cos.ForeignKey, ","), strings.Join(cos.ColumnName, ","); fk != "org_id,shop_id" || ref != "org_id,id" {
+		t.Errorf("expected (org_id,shop_id) -> (org_id,id), found (%s) -> (%s)", fk, ref)
+	}
+	expected := "CONSTRAINT `fk_shop` FOREIGN KEY (`org_id`,`shop_id`) REFERENCES `shop` (`org_id`,`id`) ON DELETE CASCADE"
+	if sql := cos.SQL(); sql != expected {
+		t.Errorf("expected %s, found %s", expected, sql)
+	}
+
+	sqlStmt = "CREATE TABLE `item` (\n  `org_id` int,\n  `shop_id` int,\n" +
+		"  CONSTRAINT `fk_shop` FOREIGN KEY (`org_id`, `shop_id`) REFERENCES `shop` (`id`)\n);"
+	_, err = ParseString(sqlStmt)
+	if err == nil || !strings.Contains(err.Error(), "foreign key has 2 columns but references 1") {
+		t.Errorf("expected column count mismatch error, found %v", err)
+	}
+}
+
+func TestParserInlineReferences(t *testing.T) {
+	sqlStmt := "CREATE TABLE `node` (\n  `id` int NOT NULL,\n  `parent_id` int REFERENCES `node` (`id`) ON DELETE CASCADE,\n" +
+		"  `owner_id` int NOT NULL REFERENCES geo.`user`(`id`) COMMENT 'owner',\n" +
+		"  CONSTRAINT `fk_owner` FOREIGN KEY (`owner_id`) REFERENCES `account` (`id`)\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := schema["node"]
+	if s := strings.Join(node.ConstraintOrder, ","); s != "node_ibfk_1,node_ibfk_2,fk_owner" {
+		t.Fatalf("expected constraints node_ibfk_1,node_ibfk_2,fk_owner, found %s", s)
+	}
+	expected := "CONSTRAINT `node_ibfk_1` FOREIGN KEY (`parent_id`) REFERENCES `node` (`id`) ON DELETE CASCADE"
+	if sql := node.Constraints["node_ibfk_1"].SQL(); sql != expected {
+		t.Errorf("expected %s, found %s", expected, sql)
+	}
+	if cos := node.Constraints["node_ibfk_2"]; cos.ReferencedDatabase != "geo" || cos.TableName != "user" {
+		t.Errorf("expected reference to geo.user, found %+v", cos)
+	}
+	if owner := node.Columns["owner_id"]; owner.Nullable || owner.Comment != "owner" {
+		t.Errorf("expected attributes after REFERENCES to be kept, found %+v", owner)
+	}
+
+	sqlStmt = "CREATE TABLE `node` (\n  `parent_id` int REFERENCES `node` (`id`, `kind`)\n);"
+	if _, err := ParseString(sqlStmt); err == nil {
+		t.Errorf("expected error for inline reference to two columns")
+	}
+}
+
+func TestParserConstraintsSameColumn(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `org_id` bigint(20),\n" +
+		"  CONSTRAINT `fk_org` FOREIGN KEY (`org_id`) REFERENCES `org` (`id`),\n" +
+		"  CONSTRAINT `fk_team` FOREIGN KEY (`org_id`) REFERENCES `team` (`org_id`),\n" +
+		"  CONSTRAINT FOREIGN KEY (`org_id`) REFERENCES `account` (`id`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if len(user.Constraints) != 3 {
+		t.Fatalf("expected 3 constraints, found %d", len(user.Constraints))
+	}
+	for name, table := range map[string]string{"fk_org": "org", "fk_team": "team", "user_ibfk_1": "account"} {
+		cos := user.Constraints[name]
+		if cos == nil {
+			t.Fatalf("expected constraint %s, but not found", name)
+		}
+		if strings.Join(cos.ForeignKey, ",") != "org_id" || cos.TableName != table {
+			t.Errorf("expected %s on org_id referencing %s, found %v referencing %s", name, table, cos.ForeignKey, cos.TableName)
+		}
+	}
+	if s := strings.Join(user.ConstraintOrder, ","); s != "fk_org,fk_team,user_ibfk_1" {
+		t.Errorf("expected constraint order fk_org,fk_team,user_ibfk_1, found %s", s)
+	}
+}
+
+func TestParserInsertRowCount(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20),\n  `name` varchar(20)\n);\n" +
+		"INSERT INTO `user` VALUES (1,'a (b'),(2,'c), (d');\n" +
+		"INSERT INTO `user` (`id`, `name`) VALUES (3,'e;f');\n" +
+		"INSERT INTO `user` VALUES (4,'g'),(5,'h') ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `id` = (`id` + 1);\n" +
+		"INSERT INTO `user` VALUES ROW(6,'i'), ROW(7,'j') AS `new` (`i`, `n`) ON DUPLICATE KEY UPDATE `name` = `new`.`n`;\n" +
+		"INSERT INTO `user` (`id`) SELECT `id` FROM `city` WHERE `id` IN (1, 2);\n" +
+		"CREATE TABLE `city` (\n  `id` bigint(20)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) != 2 {
+		t.Fatalf("expected 2 tables, found %d", len(schema))
+	}
+	if rows := schema["user"].RowCount; rows != 7 {
+		t.Errorf("expected 7 rows in user, found %d", rows)
+	}
+	if rows := schema["city"].RowCount; rows != 0 {
+		t.Errorf("expected no rows in city, found %d", rows)
+	}
+}
+
+func TestParserSkipUnknownStatements(t *testing.T) {
+	sqlStmt := "SET NAMES utf8mb4;\nCREATE TABLE `user` (\n  `id` bigint(20)\n);\n" +
+		"GRANT SELECT, INSERT ON `db`.* TO 'app'@'%';\nFLUSH PRIVILEGES;\nREVOKE ALL PRIVILEGES ON *.* FROM 'old'@'%';\n" +
+		"CREATE TABLE `city` (\n  `id` bigint(20)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) != 2 || schema["user"] == nil || schema["city"] == nil {
+		t.Errorf("expected tables user and city, found %v", tableNames(schema))
+	}
+}
+
+func TestParserTrailingStatementAtEOF(t *testing.T) {
+	for _, trailer := range []string{"SELECT 1", "SHOW TABLES", "SELECT 'a;b' FROM dual;"} {
+		sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20)\n);\n" + trailer
+		schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+		if err != nil {
+			t.Errorf("%s: %v", trailer, err)
+			continue
+		}
+		if len(schema) != 1 || schema["user"] == nil {
+			t.Errorf("%s: expected table user, found %v", trailer, tableNames(schema))
+		}
+	}
+}
+
+func TestParserEnumNumericMembers(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `level` enum('1','2','3') NOT NULL DEFAULT '1',\n  `flags` set('a','10')\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	level := schema["user"].Columns["level"]
+	if level.Type != "enum" || level.Size != 0 || strings.Join(level.EnumValues, ",") != "1,2,3" {
+		t.Errorf("expected enum members 1,2,3, found %s(%d) %v", level.Type, level.Size, level.EnumValues)
+	}
+	if sql := level.SQL(); sql != "`level` enum('1','2','3') NOT NULL DEFAULT '1'" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	flags := schema["user"].Columns["flags"]
+	if flags.Type != "set" || strings.Join(flags.SetValues, ",") != "a,10" {
+		t.Errorf("expected set members a,10, found %s %v", flags.Type, flags.SetValues)
+	}
+
+	sqlStmt = "CREATE TABLE `user` (\n  `level` enum(1,2)\n);"
+	if _, err := NewParser(strings.NewReader(sqlStmt)).Parse(); err == nil {
+		t.Errorf("expected error for unquoted enum members")
+	}
+}
+
+func TestParserParseAll(t *testing.T) {
+	sqlStmt := "DROP TABLE IF EXISTS `user`, `db`.`old_user`;\nCREATE TABLE `user` (\n  `id` bigint(20)\n);\nLOCK TABLES `user` WRITE;\nUNLOCK TABLES;"
+	result, err := NewParser(strings.NewReader(sqlStmt)).ParseAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Schema) != 1 || result.Schema["user"] == nil {
+		t.Errorf("expected table user, found %v", tableNames(result.Schema))
+	}
+	if dropped := strings.Join(result.Dropped, ","); dropped != "user,old_user" {
+		t.Errorf("expected dropped user,old_user, found %s", dropped)
+	}
+	if skipped := strings.Join(result.Skipped, ","); skipped != "DROP,LOCK,UNLOCK" {
+		t.Errorf("expected skipped DROP,LOCK,UNLOCK, found %s", skipped)
+	}
+}
+
+func TestParserDefinerClauses(t *testing.T) {
+	sqlStmt := "/*!50001 CREATE ALGORITHM=UNDEFINED */\n/*!50013 DEFINER=`root`@`localhost` SQL SECURITY DEFINER */\n/*!50001 VIEW `v` AS select 1 AS `a` */;\n" +
+		"/*!50013 DEFINER=`app`@`%` SQL SECURITY INVOKER */ GRANT SELECT ON `v` TO 'app'@'%';\n" +
+		"CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`localhost` SQL SECURITY DEFINER VIEW `w` AS select `id` from `user`;\n" +
+		"DELIMITER ;;\n/*!50003 CREATE*/ /*!50017 DEFINER=`root`@`%`*/ /*!50003 TRIGGER `t` BEFORE INSERT ON `user` FOR EACH ROW SET NEW.id = 1 */;;\nDELIMITER ;\n" +
+		"CREATE TABLE `user` (\n  `id` bigint(20)\n);"
+	result, err := NewParser(strings.NewReader(sqlStmt)).ParseAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Schema) != 1 || result.Schema["user"] == nil {
+		t.Errorf("expected table user, found %v", tableNames(result.Schema))
+	}
+	if skipped := strings.Join(result.Skipped, ","); skipped != "GRANT,CREATE" {
+		t.Errorf("expected skipped GRANT,CREATE, found %s", skipped)
+	}
+}
+
+func TestParserSpatialSRID(t *testing.T) {
+	sqlStmt := "CREATE TABLE `place` (\n  `location` point SRID 4326 NOT NULL,\n  `area` polygon,\n  `shape` geometry NOT NULL SRID 0\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := schema["place"].Columns["location"]
+	if location.Type != "point" || location.SRID != 4326 || location.Nullable || location.Category() != SpatialType {
+		t.Errorf("expected NOT NULL spatial point with SRID 4326, found %+v", location)
+	}
+	if area := schema["place"].Columns["area"]; area.Type != "polygon" || area.SRID != 0 {
+		t.Errorf("expected polygon without SRID, found %+v", area)
+	}
+	if sql := location.SQL(); sql != "`location` point SRID 4326 NOT NULL" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+}
+
+func TestParserEnum(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `status` enum('active','inactive','pending') NOT NULL,\n  `tag` enum('')\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := schema["user"].Columns["status"]
+	if values := status.EnumValues; len(values) != 3 || values[0] != "active" || values[1] != "inactive" || values[2] != "pending" {
+		t.Errorf("expected enum members active,inactive,pending in order, found %v", values)
+	}
+	tag := schema["user"].Columns["tag"]
+	if len(tag.EnumValues) != 1 || tag.EnumValues[0] != "" {
+		t.Errorf("expected the empty member, found %q", tag.EnumValues)
+	}
+	if sql := tag.SQL(); sql != "`tag` enum('')" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+
+	sqlStmt = "CREATE TABLE `user` (\n  `status` enum NOT NULL\n);"
+	if _, err := NewParser(strings.NewReader(sqlStmt)).Parse(); err == nil {
+		t.Errorf("expected error for enum without members")
+	}
+}
+
+func TestParserSet(t *testing.T) {
+	sqlStmt := "CREATE TABLE `post` (\n  `flags` set('a','b,c','d') NOT NULL DEFAULT 'a,d'\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := schema["post"].Columns["flags"]
+	if values := flags.SetValues; len(values) != 3 || values[0] != "a" || values[1] != "b,c" || values[2] != "d" {
+		t.Errorf("expected set members a, b,c and d in order, found %q", values)
+	}
+	if flags.EnumValues != nil {
+		t.Errorf("expected no enum members on a set column, found %q", flags.EnumValues)
+	}
+	if sql := flags.SQL(); sql != "`flags` set('a','b,c','d') NOT NULL DEFAULT 'a,d'" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+}
+
+func TestParserUnnamedKey(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `name` varchar(20),\n  `email` varchar(255),\n  KEY `name` (`email`),\n  KEY (`name`),\n  INDEX (`name`),\n  UNIQUE KEY (`email`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	expected := map[string]string{
+		"name":   "email", // explicitly named
+		"name_2": "name",
+		"name_3": "name",
+	}
+	for name, column := range expected {
+		if index := user.Indexes[name]; index == nil || index.Kind != KeyIndex || index.Parts[0].Column != column {
+			t.Errorf("expected key %s on %s, found %+v", name, column, index)
+		}
+	}
+	if index := user.Indexes["email"]; index == nil || index.Kind != UniqueIndex {
+		t.Errorf("expected unique key email, found %+v", index)
+	}
+	if len(user.Indexes) != 4 {
+		t.Errorf("expected 4 indexes, found %d", len(user.Indexes))
+	}
+}
+
+func TestParserUnsignedZerofill(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` int unsigned NOT NULL,\n  `flags` tinyint(1) unsigned zerofill,\n  `mask` int zerofill unsigned,\n  `n` int unsigned(10),\n  `m` int\n);"
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.Lint = true
+	schema, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Column{
+		"id":    {Unsigned: true},
+		"flags": {Unsigned: true, Zerofill: true, Size: 1},
+		"mask":  {Unsigned: true, Zerofill: true},
+		"n":     {Unsigned: true, Size: 10},
+		"m":     {},
+	}
+	for name, e := range expected {
+		c := schema["user"].Columns[name]
+		if c.Unsigned != e.Unsigned || c.Zerofill != e.Zerofill || c.Size != e.Size {
+			t.Errorf("column %s: expected unsigned %v zerofill %v size %d, found %v %v %d", name, e.Unsigned, e.Zerofill, e.Size, c.Unsigned, c.Zerofill, c.Size)
+		}
+	}
+	if sql := schema["user"].Columns["flags"].SQL(); sql != "`flags` tinyint(1) unsigned zerofill" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	var zerofill int
+	for _, w := range p.Warnings() {
+		if strings.Contains(w.Msg, "ZEROFILL") {
+			zerofill++
+		}
+	}
+	if zerofill != 2 {
+		t.Errorf("expected 2 ZEROFILL warnings, found %v", p.Warnings())
+	}
+}
+
+func TestParserCompositePrimaryKey(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user_role` (\n  `user_id` bigint(20) NOT NULL,\n  `role_id` bigint(20) NOT NULL,\n  PRIMARY KEY (`user_id`, `role_id`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := schema["user_role"]
+	if keys := strings.Join(table.PrimaryKeys, ","); keys != "user_id,role_id" {
+		t.Errorf("expected primary keys user_id,role_id in order, found %s", keys)
+	}
+	if table.PrimaryKey != "user_id" {
+		t.Errorf("expected deprecated primary key user_id, found %q", table.PrimaryKey)
+	}
+	if sql := table.Indexes["PRIMARY"].SQL(); sql != "PRIMARY KEY (`user_id`,`role_id`)" {
+		t.Errorf("unexpected primary key SQL %s", sql)
+	}
+	if err := table.RemoveColumn("user_id"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := strings.Join(table.PrimaryKeys, ","); keys != "role_id" || table.PrimaryKey != "role_id" {
+		t.Errorf("expected primary key role_id after removing user_id, found %s", keys)
+	}
+}
+
+func TestParserJSONAndSpatialExpressionDefault(t *testing.T) {
+	sqlStmt := "CREATE TABLE `doc` (\n  `tags` json DEFAULT (json_array()),\n  `meta` JSON NOT NULL DEFAULT (json_object('a', 1)),\n  `origin` point NOT NULL DEFAULT (point(0, 0))\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Expr{
+		"tags":   "json_array()",
+		"meta":   "json_object('a', 1)",
+		"origin": "point(0, 0)",
+	}
+	for name, expr := range expected {
+		c := schema["doc"].Columns[name]
+		if c.Default != expr {
+			t.Errorf("column %s: expected default %q, found %#v", name, expr, c.Default)
+		}
+	}
+	tags := schema["doc"].Columns["tags"]
+	if tags.Type != "json" || tags.Category() != JSONType {
+		t.Errorf("expected json column, found %s %v", tags.Type, tags.Category())
+	}
+	if sql := tags.SQL(); sql != "`tags` json DEFAULT (json_array())" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+}
+
+func TestParserMultiColumnKeys(t *testing.T) {
+	sqlStmt := "CREATE TABLE `order` (\n  `id` int,\n  `user_id` int,\n  `shop_id` int,\n  `created` datetime,\n  KEY `idx_user` (`user_id`, `shop_id`, `created`),\n  UNIQUE KEY `uk_shop` (`shop_id`,`id`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := schema["order"]
+	if keys := order.Keys["idx_user"]; strings.Join(keys, ",") != "user_id,shop_id,created" {
+		t.Errorf("expected key columns user_id,shop_id,created, found %v", keys)
+	}
+	if keys := order.UniqueKeys["uk_shop"]; strings.Join(keys, ",") != "shop_id,id" {
+		t.Errorf("expected unique key columns shop_id,id, found %v", keys)
+	}
+	clone := order.Clone()
+	clone.Keys["idx_user"][0] = "changed"
+	if order.Keys["idx_user"][0] != "user_id" {
+		t.Errorf("expected clone not to share key columns")
+	}
+}
+
+func TestParserDeclarationOrder(t *testing.T) {
+	sqlStmt := "CREATE TABLE `order` (\n  `zone` int,\n  `id` int,\n  `buyer` int,\n  PRIMARY KEY (`id`),\n  KEY `z_idx` (`zone`),\n  KEY `a_idx` (`buyer`),\n" +
+		"  CONSTRAINT `fk_zone` FOREIGN KEY (`zone`) REFERENCES `zone` (`id`),\n  CONSTRAINT `fk_buyer` FOREIGN KEY (`buyer`) REFERENCES `user` (`id`)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := schema["order"]
+	if s := strings.Join(order.ColumnOrder, ","); s != "zone,id,buyer" {
+		t.Errorf("expected column order zone,id,buyer, found %s", s)
+	}
+	if s := strings.Join(order.IndexOrder, ","); s != "PRIMARY,z_idx,a_idx" {
+		t.Errorf("expected index order PRIMARY,z_idx,a_idx, found %s", s)
+	}
+	if s := strings.Join(order.ConstraintOrder, ","); s != "fk_zone,fk_buyer" {
+		t.Errorf("expected constraint order fk_zone,fk_buyer, found %s", s)
+	}
+	if err := order.RemoveColumn("zone"); err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(order.ColumnOrder, ","); s != "id,buyer" {
+		t.Errorf("expected column order id,buyer after removal, found %s", s)
+	}
+	if s := strings.Join(order.IndexOrder, ","); s != "PRIMARY,a_idx" {
+		t.Errorf("expected index order PRIMARY,a_idx after removal, found %s", s)
+	}
+	if s := strings.Join(order.ConstraintOrder, ","); s != "fk_buyer" {
+		t.Errorf("expected constraint order fk_buyer after removal, found %s", s)
+	}
+}
+
+func TestParserDelimiter(t *testing.T) {
+	sqlStmt := "DELIMITER $$\nDROP PROCEDURE IF EXISTS `cleanup`$$\nSET @a = 1; SET @b = 'x$$y'$$\n" +
+		"CREATE TABLE `log` (\n  `id` int,\n  `msg` varchar(20) DEFAULT ';'\n) ENGINE=InnoDB$$\nDELIMITER ;\n" +
+		"CREATE TABLE `user` (\n  `id` int\n);\n"
+	p := NewParser(strings.NewReader(sqlStmt))
+	result, err := p.ParseAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Schema) != 2 || result.Schema["log"] == nil || result.Schema["user"] == nil {
+		t.Fatalf("expected tables log and user, found %v", result.Schema)
+	}
+	if engine := result.Schema["log"].Extras["ENGINE"]; engine != "InnoDB" {
+		t.Errorf("expected engine InnoDB, found %q", engine)
+	}
+	if s := strings.Join(result.Skipped, ","); s != "DROP,SET" {
+		t.Errorf("expected skipped DROP,SET, found %s", s)
+	}
+
+	if _, err := NewParser(strings.NewReader("DELIMITER\nCREATE TABLE `t` (`id` int);")).Parse(); err == nil {
+		t.Errorf("expected error for missing delimiter")
+	}
+}
+
+func TestParseString(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` int\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["user"] == nil || schema["user"].Columns["id"] == nil {
+		t.Errorf("expected table user with column id, found %v", schema)
+	}
+	if _, err := ParseString("CREATE TABLE ("); err == nil {
+		t.Errorf("expected error for invalid statement")
+	}
+}
+
+func TestParserInterleavedDefinitions(t *testing.T) {
+	sqlStmt := "CREATE TABLE `order` (\n  `id` int NOT NULL,\n  KEY `idx_buyer` (`buyer_id`),\n  `buyer_id` int,\n" +
+		"  CONSTRAINT `fk_buyer` FOREIGN KEY (`buyer_id`) REFERENCES `user` (`id`),\n  PRIMARY KEY (`id`),\n" +
+		"  `code` varchar(20) UNIQUE,\n  CHECK (`id` > 0),\n  `note` text,\n  UNIQUE KEY `uk_note` (`note`(10)),\n" +
+		"  `created` datetime DEFAULT CURRENT_TIMESTAMP,\n  KEY (`created`)\n) ENGINE=InnoDB;\nCREATE TABLE `user` (\n  `id` int\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := schema["order"]
+	if s := strings.Join(order.ColumnOrder, ","); s != "id,buyer_id,code,note,created" {
+		t.Errorf("expected columns id,buyer_id,code,note,created, found %s", s)
+	}
+	if s := strings.Join(order.IndexOrder, ","); s != "idx_buyer,PRIMARY,code,uk_note,created" {
+		t.Errorf("expected indexes idx_buyer,PRIMARY,code,uk_note,created, found %s", s)
+	}
+	if cos := order.Constraints["fk_buyer"]; cos == nil || strings.Join(cos.ForeignKey, ",") != "buyer_id" {
+		t.Errorf("expected constraint fk_buyer, found %+v", cos)
+	}
+	if len(order.Checks) != 1 {
+		t.Errorf("expected 1 check, found %d", len(order.Checks))
+	}
+	if order.Extras["ENGINE"] != "InnoDB" {
+		t.Errorf("expected engine InnoDB, found %v", order.Extras)
+	}
+	if schema["user"] == nil {
+		t.Errorf("expected table user after the interleaved table")
+	}
+}
+
+func TestParserTrailingDashComment(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` int\n);\n-- Dump completed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["user"] == nil {
+		t.Errorf("expected table user, found %v", schema)
+	}
+}
+
+func TestParserUnterminatedComment(t *testing.T) {
+	_, err := ParseString("CREATE TABLE `user` (\n  `id` int\n);\n/* unterminated")
+	if err == nil || !strings.Contains(err.Error(), "unterminated /* comment") {
+		t.Errorf("expected unterminated comment error, found %v", err)
+	}
+}
+
+func TestParserTableOptions(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` int\n) ENGINE=InnoDB AUTO_INCREMENT=5 DEFAULT CHARSET=utf8 COMMENT='x';\n" +
+		"CREATE TABLE `city` (\n  `id` int\n) DEFAULT CHARACTER SET=utf8mb4 DEFAULT COLLATE=utf8mb4_bin;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"ENGINE": "InnoDB", "AUTO_INCREMENT": "5", "CHARSET": "utf8", "COMMENT": "x"}
+	if user := schema["user"]; fmt.Sprint(user.Extras) != fmt.Sprint(expected) || user.Charset != "utf8" {
+		t.Errorf("expected options %v, found %v", expected, user.Extras)
+	}
+	expected = map[string]string{"CHARSET": "utf8mb4", "COLLATE": "utf8mb4_bin"}
+	if city := schema["city"]; fmt.Sprint(city.Extras) != fmt.Sprint(expected) || city.Charset != "utf8mb4" {
+		t.Errorf("expected options %v, found %v", expected, city.Extras)
+	}
+
+	for _, options := range []string{"DEFAULT ENGINE=InnoDB", "DEFAULT COMMENT='x'", "DEFAULT AUTO_INCREMENT=5"} {
+		if _, err := ParseString("CREATE TABLE `t` (\n  `id` int\n) " + options + ";"); err == nil || !strings.Contains(err.Error(), "expected CHARSET") {
+			t.Errorf("%s: expected DEFAULT prefix error, found %v", options, err)
+		}
+	}
+}
+
+func TestParserHashComment(t *testing.T) {
+	schema, err := ParseString("# Host: localhost\nCREATE TABLE `user` (\n  `id` int,\n  `name` varchar(20) DEFAULT '#'\n);\n# Dump completed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user := schema["user"]; user == nil || len(user.Columns) != 2 || user.Columns["name"].Default != "#" {
+		t.Errorf("expected table user with columns id and name, found %v", schema)
+	}
+}
+
+func TestParserEscapedComments(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `name` varchar(20) DEFAULT 'it''s' COMMENT 'the user''s name',\n" +
+		"  `note` text COMMENT 'line1\\nline2 \\'quoted\\''\n) COMMENT='Bob''s table';")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if c := user.Columns["name"]; c.Default != "it's" || c.Comment != "the user's name" {
+		t.Errorf("expected default it's and comment the user's name, found %v %q", c.Default, c.Comment)
+	}
+	if c := user.Columns["note"]; c.Comment != "line1\nline2 'quoted'" {
+		t.Errorf("expected unescaped comment, found %q", c.Comment)
+	}
+	if c := user.Extras["COMMENT"]; c != "Bob's table" {
+		t.Errorf("expected table comment Bob's table, found %q", c)
+	}
+	again, err := ParseString(user.SQL() + ";")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again["user"].SQL() != user.SQL() {
+		t.Errorf("expected round trip, found:\n%s\n%s", user.SQL(), again["user"].SQL())
+	}
+}
+
+func TestParserTableOptionsWithComments(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `id` int\n) ENGINE=InnoDB\n/* c */ DEFAULT CHARSET=utf8\n-- comment\n  COMMENT='x' /* a */ /* b */;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"ENGINE": "InnoDB", "CHARSET": "utf8", "COMMENT": "x"}
+	if extras := schema["user"].Extras; fmt.Sprint(extras) != fmt.Sprint(expected) {
+		t.Errorf("expected options %v, found %v", expected, extras)
+	}
+}
+
+func TestParserNumericDefaults(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `item` (\n  `age` int DEFAULT 0,\n  `price` decimal(10,2) DEFAULT 0.50,\n  `active` tinyint(1) DEFAULT 1,\n" +
+		"  `delta` int NOT NULL DEFAULT -5,\n  `ratio` double DEFAULT -.25,\n  `big` bigint unsigned DEFAULT 18446744073709551615,\n" +
+		"  `enabled` bool DEFAULT TRUE,\n  `deleted` boolean DEFAULT false\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"age":     int64(0),
+		"price":   Number("0.50"),
+		"active":  int64(1),
+		"delta":   int64(-5),
+		"ratio":   Number("-.25"),
+		"big":     uint64(18446744073709551615),
+		"enabled": true,
+		"deleted": false,
+	}
+	item := schema["item"]
+	for name, value := range expected {
+		if c := item.Columns[name]; c.Default != value {
+			t.Errorf("%s: expected default %#v, found %#v", name, value, c.Default)
+		}
+	}
+	if sql := item.Columns["price"].SQL(); sql != "`price` decimal(10,2) DEFAULT 0.50" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	for _, literal := range []string{"1e3", "1E3", "1.5e-3", "2.E+2", "1.", "-1.e3"} {
+		schema, err := ParseString("CREATE TABLE `t` (\n  `a` double DEFAULT " + literal + ",\n  `b` int\n);")
+		if err != nil {
+			t.Errorf("%s: %v", literal, err)
+		} else if c := schema["t"].Columns["a"]; c.Default != Number(literal) || len(schema["t"].Columns) != 2 {
+			t.Errorf("%s: expected the literal as default, found %#v", literal, c.Default)
+		}
+	}
+	for _, literal := range []string{"-x", ".", "1e", "1e+", "1ex", "-.e3"} {
+		if _, err := ParseString("CREATE TABLE `t` (\n  `a` double DEFAULT " + literal + "\n);"); err == nil {
+			t.Errorf("%s: expected error for invalid number", literal)
+		}
+	}
+}
+
+func TestParserANSIQuotes(t *testing.T) {
+	sqlStmt := "CREATE TABLE \"user\" (\n  \"id\" int,\n  \"name\" varchar(20) DEFAULT 'x',\n  KEY \"idx_name\" (\"name\")\n);"
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.ANSIQuotes = true
+	schema, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if user == nil || user.Columns["id"] == nil || user.Columns["name"].Default != "x" || user.Indexes["idx_name"] == nil {
+		t.Fatalf("expected double quoted identifiers, found %+v", user)
+	}
+	if _, err := NewParser(strings.NewReader(sqlStmt)).Parse(); err == nil {
+		t.Errorf("expected double quoted table name to be a string without ANSIQuotes")
+	}
+}
+
+func TestParserNegativeDefaults(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `account` (\n  `balance` int DEFAULT -1,\n  `limit` int DEFAULT - 100,\n" +
+		"  `rate` decimal(5,2) DEFAULT -0.75,\n  `label` varchar(10) DEFAULT '-1'\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"balance": int64(-1), "limit": int64(-100), "rate": Number("-0.75"), "label": "-1"}
+	account := schema["account"]
+	for name, value := range expected {
+		if c := account.Columns[name]; c.Default != value {
+			t.Errorf("%s: expected default %#v, found %#v", name, value, c.Default)
+		}
+	}
+	if sql := account.Columns["limit"].SQL(); sql != "`limit` int DEFAULT -100" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+}
+
+func TestParserOnUpdateCurrentTimestamp(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `user` (\n  `updated_at` timestamp DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP NOT NULL COMMENT 'x',\n" +
+		"  `touched_at` datetime(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+		"  `seen_at` datetime ON UPDATE CURRENT_TIMESTAMP() DEFAULT NULL\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if c := user.Columns["updated_at"]; c.OnUpdate != "current_timestamp" || c.Nullable || c.Comment != "x" {
+		t.Errorf("expected ON UPDATE CURRENT_TIMESTAMP followed by NOT NULL and COMMENT, found %+v", c)
+	}
+	c := user.Columns["touched_at"]
+	if c.Default != "current_timestamp(3)" || c.OnUpdate != "current_timestamp(3)" {
+		t.Errorf("expected CURRENT_TIMESTAMP(3), found %v %q", c.Default, c.OnUpdate)
+	}
+	if sql := c.SQL(); sql != "`touched_at` datetime(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	if c := user.Columns["seen_at"]; c.OnUpdate != "current_timestamp" || c.Default != "null" {
+		t.Errorf("expected ON UPDATE CURRENT_TIMESTAMP before DEFAULT NULL, found %v %q", c.Default, c.OnUpdate)
+	}
+}
+
+func TestParserGeneratedColumns(t *testing.T) {
+	sqlStmt := "CREATE TABLE `order` (\n  `price` int NOT NULL,\n  `qty` int NOT NULL,\n" +
+		"  `total` int GENERATED ALWAYS AS (`price` * `qty`) STORED NOT NULL,\n" +
+		"  `label` varchar(40) AS (concat('#', `qty`)) VIRTUAL COMMENT 'display',\n" +
+		"  `half` int AS (`price` / 2) NULL CHECK (`half` >= 0) NOT ENFORCED,\n" +
+		"  KEY `idx_total` (`total`, `qty`)\n);"
+	schema, err := ParseString(sqlStmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := schema["order"]
+	total := order.Columns["total"]
+	if total.Generated != "price * qty" || !total.Stored || total.Nullable || total.RequiredOnInsert() {
+		t.Errorf("expected stored NOT NULL generated column, found %+v", total)
+	}
+	if keys := order.Keys["idx_total"]; strings.Join(keys, ",") != "total,qty" {
+		t.Errorf("expected key idx_total on total,qty, found %v", keys)
+	}
+	if sql := total.SQL(); sql != "`total` int GENERATED ALWAYS AS (price * qty) STORED NOT NULL" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	label := order.Columns["label"]
+	if label.Generated != "concat('#', qty)" || label.Stored || label.Comment != "display" {
+		t.Errorf("expected virtual generated column with comment, found %+v", label)
+	}
+	half := order.Columns["half"]
+	if half.Generated != "price / 2" || !half.Nullable {
+		t.Errorf("expected nullable generated column, found %+v", half)
+	}
+	for _, check := range order.Checks {
+		if check.Enforced {
+			t.Errorf("expected check %s NOT ENFORCED", check.Name)
+		}
+	}
+	again, err := ParseString(order.SQL() + ";")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again["order"].SQL() != order.SQL() {
+		t.Errorf("expected round trip, found:\n%s\n%s", order.SQL(), again["order"].SQL())
+	}
+}
@@ -0,0 +1,522 @@
+package sqlparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RequiredOnInsert reports whether an INSERT must supply a value for the
+// column, i.e. it is NOT NULL without a default, not auto increment and
+// not generated
+func (c *Column) RequiredOnInsert() bool {
+	return !c.Nullable && c.Default == nil && !c.AutoIncr && c.Generated == ""
+}
+
+// NormalizedCharset returns the table charset in lower case with the
+// deprecated utf8 alias resolved to utf8mb3, as MySQL 8 does
+func (t *Table) NormalizedCharset() string {
+	return normalizeCharset(t.Charset)
+}
+
+func normalizeCharset(charset string) string {
+	charset = strings.ToLower(charset)
+	if charset == "utf8" {
+		return "utf8mb3"
+	}
+	return charset
+}
+
+// NormalizeNullability marks the primary key columns NOT NULL, as MySQL
+// implicitly does even when they are declared nullable
+func (t *Table) NormalizeNullability() {
+	primary := t.Indexes["PRIMARY"]
+	if primary == nil {
+		return
+	}
+	for _, part := range primary.Parts {
+		if c := t.Columns[part.Column]; c != nil {
+			c.Nullable = false
+		}
+	}
+}
+
+// PrimaryKeyColumns returns the primary key columns in key order, nil when
+// the table has no primary key
+func (t *Table) PrimaryKeyColumns() []*Column {
+	primary := t.Indexes["PRIMARY"]
+	if primary == nil {
+		return nil
+	}
+	var columns []*Column
+	for _, part := range primary.Parts {
+		if c := t.Columns[part.Column]; c != nil {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// EffectiveCharset returns the charset of a string column, inherited from
+// the table DEFAULT CHARSET unless the column declares its own charset or
+// collation. Columns of other types have no charset.
+func (c *Column) EffectiveCharset(t *Table) string {
+	switch {
+	case c.Category() != StringType:
+		return ""
+	case c.Charset != "":
+		return c.Charset
+	case c.Collation != "":
+		return collationCharset(c.Collation)
+	case t.Charset != "":
+		return t.Charset
+	}
+	return collationCharset(t.option("COLLATE"))
+}
+
+// EffectiveCollation returns the collation of a string column, inherited
+// from the table COLLATE unless the column declares its own. It is empty
+// when the column changes the charset without a collation, as the default
+// collation of a charset depends on the server.
+func (c *Column) EffectiveCollation(t *Table) string {
+	switch {
+	case c.Category() != StringType:
+		return ""
+	case c.Collation != "":
+		return c.Collation
+	case c.Charset != "" && normalizeCharset(c.Charset) != t.NormalizedCharset():
+		return ""
+	}
+	return t.option("COLLATE")
+}
+
+// collationCharset returns the charset a collation belongs to, e.g.
+// utf8mb4 for utf8mb4_bin
+func collationCharset(collation string) string {
+	if i := strings.Index(collation, "_"); i > 0 {
+		return collation[:i]
+	}
+	return collation
+}
+
+// RemoveTable removes the named table along with the foreign key
+// constraints of other tables that reference it
+func (s Schema) RemoveTable(name string) error {
+	return s.removeTable(name, true)
+}
+
+// RemoveTableRestrict removes the named table, it refuses to do so while
+// other tables still reference it
+func (s Schema) RemoveTableRestrict(name string) error {
+	return s.removeTable(name, false)
+}
+
+func (s Schema) removeTable(name string, cascade bool) error {
+	if _, ok := s[name]; !ok {
+		return fmt.Errorf("table %q not found", name)
+	}
+	for _, table := range s {
+		if table.Name == name {
+			continue
+		}
+		for key, cos := range table.Constraints {
+			if cos.TableName != name {
+				continue
+			}
+			if !cascade {
+				return fmt.Errorf("table %q is referenced by constraint %q of table %q", name, cos.Index, table.Name)
+			}
+			table.removeConstraint(key)
+		}
+	}
+	delete(s, name)
+	return nil
+}
+
+// RemoveColumn removes the named column, the column is dropped from the
+// keys containing it and keys left without columns are removed, as are
+// the foreign key constraints defined on it or referencing it from this
+// table and the checks using it
+func (t *Table) RemoveColumn(name string) error {
+	if _, ok := t.Columns[name]; !ok {
+		return fmt.Errorf("column %q not found in table %q", name, t.Name)
+	}
+	delete(t.Columns, name)
+	t.ColumnOrder = removeName(t.ColumnOrder, name)
+	for _, index := range t.Indexes {
+		var parts []KeyPart
+		for _, part := range index.Parts {
+			if part.Column != name {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == len(index.Parts) {
+			continue
+		}
+		index.Parts = parts
+		t.syncIndex(index)
+	}
+	for key, cos := range t.Constraints {
+		columns := cos.ForeignKey
+		if cos.TableName == t.Name && (cos.ReferencedDatabase == "" || cos.ReferencedDatabase == t.Database) {
+			columns = append(append([]string(nil), columns...), cos.ColumnName...)
+		}
+		for _, column := range columns {
+			if column == name {
+				t.removeConstraint(key)
+				break
+			}
+		}
+	}
+	for key, check := range t.Checks {
+		if check.uses(name) {
+			delete(t.Checks, key)
+		}
+	}
+	return nil
+}
+
+// uses reports whether the check expression refers to the column, column
+// names being case insensitive
+func (c *Check) uses(column string) bool {
+	s := NewScanner(strings.NewReader(c.Expr))
+	for {
+		tok, lit := s.Scan()
+		if tok == EOF {
+			return false
+		}
+		if tok == IDENT && strings.EqualFold(lit, column) {
+			return true
+		}
+	}
+}
+
+// addColumn adds a column or replaces the one of the same name, a new
+// column is appended to the declaration order
+func (t *Table) addColumn(column *Column) {
+	if t.Columns[column.Name] == nil {
+		t.ColumnOrder = append(t.ColumnOrder, column.Name)
+	}
+	t.Columns[column.Name] = column
+}
+
+// addConstraint adds a foreign key constraint keyed by its name, unnamed
+// constraints are named after the table the way InnoDB does
+func (t *Table) addConstraint(cos *Constraint) {
+	for n := 1; cos.Index == ""; n++ {
+		if name := fmt.Sprintf("%s_ibfk_%d", t.Name, n); t.Constraints[name] == nil {
+			cos.Index = name
+		}
+	}
+	if t.Constraints[cos.Index] == nil {
+		t.ConstraintOrder = append(t.ConstraintOrder, cos.Index)
+	}
+	t.Constraints[cos.Index] = cos
+}
+
+func (t *Table) removeConstraint(key string) {
+	delete(t.Constraints, key)
+	t.ConstraintOrder = removeName(t.ConstraintOrder, key)
+}
+
+// moveColumn moves the column name right after the column after in
+// ColumnOrder, to the front when after is empty
+func (t *Table) moveColumn(name, after string) {
+	order := removeName(t.ColumnOrder, name)
+	i := 0
+	if after != "" {
+		i = len(order)
+		for j, n := range order {
+			if n == after {
+				i = j + 1
+			}
+		}
+	}
+	t.ColumnOrder = append(order[:i:i], append([]string{name}, order[i:]...)...)
+}
+
+// removeName returns names without name, keeping the order
+func removeName(names []string, name string) []string {
+	var kept []string
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// addIndex adds an index and records it in the key maps, an unnamed index
+// is named after its first column the way MySQL does
+func (t *Table) addIndex(index *Index) {
+	if index.Name == "" {
+		name := "functional_index"
+		if len(index.Parts) > 0 && index.Parts[0].Column != "" {
+			name = index.Parts[0].Column
+		}
+		index.Name = t.indexName(name)
+	}
+	if t.Indexes[index.Name] == nil {
+		t.IndexOrder = append(t.IndexOrder, index.Name)
+	}
+	t.Indexes[index.Name] = index
+	t.syncIndex(index)
+}
+
+// renameIndex renames index in place, keeping its position in IndexOrder
+func (t *Table) renameIndex(index *Index, name string) {
+	for i, n := range t.IndexOrder {
+		if n == index.Name {
+			t.IndexOrder[i] = name
+		}
+	}
+	delete(t.Indexes, index.Name)
+	parts := index.Parts
+	index.Parts = nil
+	t.syncIndex(index)
+	index.Name, index.Parts = name, parts
+	t.Indexes[name] = index
+	t.syncIndex(index)
+}
+
+// indexName returns name, suffixed with _2, _3... if an index of that name
+// already exists, the way MySQL names implicit indexes
+func (t *Table) indexName(name string) string {
+	if t.Indexes[name] == nil {
+		return name
+	}
+	for n := 2; ; n++ {
+		if s := fmt.Sprintf("%s_%d", name, n); t.Indexes[s] == nil {
+			return s
+		}
+	}
+}
+
+// syncIndex updates the key maps after the parts of index changed, an
+// index without parts is removed
+func (t *Table) syncIndex(index *Index) {
+	var columns []string
+	for _, part := range index.Parts {
+		columns = append(columns, part.String())
+	}
+	if len(columns) == 0 {
+		delete(t.Indexes, index.Name)
+		t.IndexOrder = removeName(t.IndexOrder, index.Name)
+	}
+	switch index.Kind {
+	case PrimaryIndex:
+		t.PrimaryKey = ""
+		if len(columns) > 0 {
+			t.PrimaryKey = columns[0]
+		}
+		t.PrimaryKeys = columns
+	case UniqueIndex:
+		setKey(t.UniqueKeys, index.Name, columns)
+	case KeyIndex:
+		setKey(t.Keys, index.Name, columns)
+	case FulltextIndex:
+		setKey(t.FulltextKeys, index.Name, columns)
+	case SpatialIndex:
+		setKey(t.SpatialKeys, index.Name, columns)
+	}
+}
+
+// setKey stores the columns of the named key, no columns remove it
+func setKey(keys map[string][]string, name string, columns []string) {
+	if len(columns) == 0 {
+		delete(keys, name)
+	} else {
+		keys[name] = columns
+	}
+}
+
+// addCheck adds a check constraint, unnamed checks are named after the
+// table the way MySQL does
+func (t *Table) addCheck(check *Check) {
+	for n := 1; check.Name == ""; n++ {
+		if name := fmt.Sprintf("%s_chk_%d", t.Name, n); t.Checks[name] == nil {
+			check.Name = name
+		}
+	}
+	t.Checks[check.Name] = check
+}
+
+// TopoSort returns the table names ordered so that every table comes after
+// the tables its foreign keys reference. Self references and references to
+// tables outside the schema are ignored, a reference cycle is an error.
+func (s Schema) TopoSort() ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	names := tableNames(s)
+
+	var sorted []string
+	state := make(map[string]int)
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("foreign key cycle through table %q", name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		var refs []string
+		for _, cos := range s[name].Constraints {
+			if cos.TableName != name && s[cos.TableName] != nil {
+				refs = append(refs, cos.TableName)
+			}
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		sorted = append(sorted, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// CreateStatements returns the CREATE TABLE statements of the schema in
+// foreign key dependency order, so they can be replayed with foreign key
+// checks enabled
+func (s Schema) CreateStatements() ([]string, error) {
+	names, err := s.TopoSort()
+	if err != nil {
+		return nil, err
+	}
+	stmts := make([]string, 0, len(names))
+	for _, name := range names {
+		stmts = append(stmts, s[name].SQL())
+	}
+	return stmts, nil
+}
+
+// ParseCommentMetadata splits a comment such as 'pii=true;encrypted=false'
+// on sep into key value pairs, a pair without = maps to an empty value
+func ParseCommentMetadata(comment, sep string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(comment, sep) {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			metadata[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		} else {
+			metadata[pair] = ""
+		}
+	}
+	return metadata
+}
+
+// Clone returns a deep copy of the schema
+func (s Schema) Clone() Schema {
+	clone := make(Schema, len(s))
+	for name, table := range s {
+		clone[name] = table.Clone()
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the table
+func (t *Table) Clone() *Table {
+	clone := *t
+	clone.Columns = make(map[string]*Column, len(t.Columns))
+	for name, column := range t.Columns {
+		c := *column
+		c.Metadata = copyStringMap(column.Metadata)
+		c.EnumValues = append([]string(nil), column.EnumValues...)
+		c.SetValues = append([]string(nil), column.SetValues...)
+		clone.Columns[name] = &c
+	}
+	clone.PrimaryKeys = append([]string(nil), t.PrimaryKeys...)
+	clone.ColumnOrder = append([]string(nil), t.ColumnOrder...)
+	clone.IndexOrder = append([]string(nil), t.IndexOrder...)
+	clone.ConstraintOrder = append([]string(nil), t.ConstraintOrder...)
+	clone.UniqueKeys = copyKeyMap(t.UniqueKeys)
+	clone.Keys = copyKeyMap(t.Keys)
+	clone.FulltextKeys = copyKeyMap(t.FulltextKeys)
+	clone.SpatialKeys = copyKeyMap(t.SpatialKeys)
+	clone.Indexes = make(map[string]*Index, len(t.Indexes))
+	for name, index := range t.Indexes {
+		i := *index
+		i.Parts = append([]KeyPart(nil), index.Parts...)
+		i.Options = copyStringMap(index.Options)
+		clone.Indexes[name] = &i
+	}
+	clone.Constraints = make(map[string]*Constraint, len(t.Constraints))
+	for name, cos := range t.Constraints {
+		c := *cos
+		c.ForeignKey = append([]string(nil), cos.ForeignKey...)
+		c.ColumnName = append([]string(nil), cos.ColumnName...)
+		clone.Constraints[name] = &c
+	}
+	clone.Checks = make(map[string]*Check, len(t.Checks))
+	for name, check := range t.Checks {
+		c := *check
+		clone.Checks[name] = &c
+	}
+	clone.Extras = copyStringMap(t.Extras)
+	return &clone
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func copyKeyMap(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string][]string, len(m))
+	for k, v := range m {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// option returns the value of the table option key, matched case
+// insensitively
+func (t *Table) option(key string) string {
+	for k, v := range t.Extras {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// setOption sets the table option key, matched case insensitively and
+// keeping the spelling of the dump, an empty value removes the option
+func (t *Table) setOption(key, value string) {
+	for k := range t.Extras {
+		if strings.EqualFold(k, key) {
+			key = k
+		}
+	}
+	if value == "" {
+		delete(t.Extras, key)
+		return
+	}
+	if t.Extras == nil {
+		t.Extras = make(map[string]string)
+	}
+	t.Extras[key] = value
+}
@@ -0,0 +1,272 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColumnRequiredOnInsert(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `username` varchar(20) NOT NULL,\n  `email` varchar(255) NOT NULL DEFAULT '',\n  `city_id` bigint(20)\n) ENGINE=InnoDB;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{
+		"id":       false,
+		"username": true,
+		"email":    false,
+		"city_id":  false,
+	}
+	for name, required := range expected {
+		if r := schema["user"].Columns[name].RequiredOnInsert(); r != required {
+			t.Errorf("column %s: expected required %v, found %v", name, required, r)
+		}
+	}
+}
+
+func TestTableNormalizedCharset(t *testing.T) {
+	sqlStmt := "CREATE TABLE `a` (`id` int) ENGINE=InnoDB DEFAULT CHARSET=utf8;\nCREATE TABLE `b` (`id` int) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs := schema["a"].Charset; cs != "utf8" {
+		t.Errorf("expected charset utf8, found %q", cs)
+	}
+	if cs := schema["a"].NormalizedCharset(); cs != "utf8mb3" {
+		t.Errorf("expected normalized charset utf8mb3, found %q", cs)
+	}
+	if cs := schema["b"].NormalizedCharset(); cs != "utf8mb4" {
+		t.Errorf("expected normalized charset utf8mb4, found %q", cs)
+	}
+}
+
+func TestSchemaRemoveTable(t *testing.T) {
+	schema, err := NewParser(strings.NewReader(fkSchema)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.RemoveTableRestrict("city"); err == nil {
+		t.Errorf("expected error removing referenced table city")
+	}
+	if schema["city"] == nil {
+		t.Errorf("expected city to be kept after restricted remove")
+	}
+	if err := schema.RemoveTable("city"); err != nil {
+		t.Fatal(err)
+	}
+	if schema["city"] != nil {
+		t.Errorf("expected city to be removed")
+	}
+	user := schema["user"]
+	if len(user.Constraints) != 1 {
+		t.Errorf("expected 1 constraint left on user, found %d", len(user.Constraints))
+	}
+	for _, cos := range user.Constraints {
+		if cos.TableName == "city" {
+			t.Errorf("expected constraint referencing city to be dropped")
+		}
+	}
+	if err := schema.RemoveTable("city"); err == nil {
+		t.Errorf("expected error removing missing table")
+	}
+}
+
+func TestTableRemoveColumn(t *testing.T) {
+	schema, err := NewParser(strings.NewReader(fkSchema)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if err := user.RemoveColumn("city_id"); err != nil {
+		t.Fatal(err)
+	}
+	if user.Columns["city_id"] != nil {
+		t.Errorf("expected column city_id to be removed")
+	}
+	if _, ok := user.Keys["idx_city"]; ok {
+		t.Errorf("expected key idx_city to be removed")
+	}
+	if user.Indexes["idx_city"] != nil {
+		t.Errorf("expected index idx_city to be removed")
+	}
+	for _, cos := range user.Constraints {
+		if strings.Join(cos.ForeignKey, ",") == "city_id" {
+			t.Errorf("expected constraint on city_id to be removed")
+		}
+	}
+	if err := user.RemoveColumn("id"); err != nil {
+		t.Fatal(err)
+	}
+	if user.PrimaryKey != "" || user.Indexes["PRIMARY"] != nil {
+		t.Errorf("expected primary key to be removed, found %q", user.PrimaryKey)
+	}
+	if err := user.RemoveColumn("id"); err == nil {
+		t.Errorf("expected error removing missing column")
+	}
+
+	schema, err = ParseString("CREATE TABLE `item` (\n  `price` int,\n  `cost` int,\n  `note` varchar(20),\n" +
+		"  CONSTRAINT `chk_margin` CHECK (`price` >= cost),\n  CONSTRAINT `chk_cost` CHECK (COST > 0),\n  CONSTRAINT `chk_note` CHECK (note <> 'cost')\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := schema["item"]
+	if err := item.RemoveColumn("cost"); err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Checks) != 1 || item.Checks["chk_note"] == nil {
+		t.Errorf("expected only chk_note to remain, found %v", item.Checks)
+	}
+
+	schema, err = ParseString("CREATE TABLE `node` (\n  `id` int,\n  `code` int,\n  `parent_id` int,\n  `parent_code` int,\n" +
+		"  CONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `node` (`id`),\n" +
+		"  CONSTRAINT `fk_parent_code` FOREIGN KEY (`parent_code`) REFERENCES `node` (`code`)\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := schema["node"]
+	if err := node.RemoveColumn("id"); err != nil {
+		t.Fatal(err)
+	}
+	if node.Constraints["fk_parent"] != nil || node.Constraints["fk_parent_code"] == nil {
+		t.Errorf("expected only fk_parent_code to remain, found %v", node.constraintNames())
+	}
+}
+
+const fkSchema = "CREATE TABLE `country` (\n  `id` bigint(20) NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB;\n" +
+	"CREATE TABLE `city` (\n  `id` bigint(20) NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB;\n" +
+	"CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL,\n  `country_id` bigint(20) NOT NULL,\n  `city_id` bigint(20) DEFAULT NULL,\n" +
+	"  PRIMARY KEY (`id`),\n  KEY `idx_city` (`city_id`),\n  KEY `idx_country` (`country_id`),\n" +
+	"  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`),\n" +
+	"  CONSTRAINT `fk_country` FOREIGN KEY (`country_id`) REFERENCES `country` (`id`)\n) ENGINE=InnoDB;"
+
+func TestSchemaCreateStatements(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` int,\n  `city_id` int,\n  CONSTRAINT `fk_city` FOREIGN KEY (`city_id`) REFERENCES `city` (`id`)\n);\n" +
+		"CREATE TABLE `city` (\n  `id` int,\n  `country_id` int,\n  `parent_id` int,\n" +
+		"  CONSTRAINT `fk_country` FOREIGN KEY (`country_id`) REFERENCES `country` (`id`),\n" +
+		"  CONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `city` (`id`)\n);\n" +
+		"CREATE TABLE `country` (\n  `id` int\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts, err := schema.CreateStatements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := []string{"country", "city", "user"}
+	if len(stmts) != len(order) {
+		t.Fatalf("expected %d statements, found %d", len(order), len(stmts))
+	}
+	for i, name := range order {
+		if !strings.HasPrefix(stmts[i], "CREATE TABLE `"+name+"`") {
+			t.Errorf("expected statement %d to create %s, found %s", i, name, stmts[i])
+		}
+	}
+
+	schema["country"].Constraints["country_ibfk_1"] = &Constraint{Index: "country_ibfk_1", ForeignKey: []string{"id"}, TableName: "user", ColumnName: []string{"id"}}
+	if _, err := schema.CreateStatements(); err == nil {
+		t.Errorf("expected error for foreign key cycle")
+	}
+}
+
+func TestColumnMetadata(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `email` varchar(255) COMMENT 'pii=true; encrypted=false;audit',\n  `name` varchar(20)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := schema["user"].Columns["email"].Metadata; md != nil {
+		t.Errorf("expected no metadata by default, found %v", md)
+	}
+
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.MetadataSeparator = ";"
+	if schema, err = p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	email := schema["user"].Columns["email"]
+	if email.Comment != "pii=true; encrypted=false;audit" {
+		t.Errorf("expected raw comment to be preserved, found %q", email.Comment)
+	}
+	expected := map[string]string{"pii": "true", "encrypted": "false", "audit": ""}
+	if len(email.Metadata) != len(expected) {
+		t.Errorf("expected metadata %v, found %v", expected, email.Metadata)
+	}
+	for k, v := range expected {
+		if found, ok := email.Metadata[k]; !ok || found != v {
+			t.Errorf("expected %s=%q, found %q", k, v, found)
+		}
+	}
+	if md := schema["user"].Columns["name"].Metadata; md != nil {
+		t.Errorf("expected no metadata without comment, found %v", md)
+	}
+}
+
+func TestTableNormalizeNullability(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` bigint(20),\n  `name` varchar(20),\n  PRIMARY KEY (`id`)\n);\nCREATE TABLE `log` (\n  `msg` varchar(20)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	if !user.Columns["id"].Nullable {
+		t.Fatalf("expected id to be parsed as declared, nullable")
+	}
+	user.NormalizeNullability()
+	if user.Columns["id"].Nullable {
+		t.Errorf("expected primary key column id to be NOT NULL")
+	}
+	if !user.Columns["name"].Nullable {
+		t.Errorf("expected name to stay nullable")
+	}
+	schema["log"].NormalizeNullability()
+	if !schema["log"].Columns["msg"].Nullable {
+		t.Errorf("expected msg to stay nullable without primary key")
+	}
+}
+
+func TestTablePrimaryKeyColumns(t *testing.T) {
+	schema, err := ParseString("CREATE TABLE `member` (\n  `user_id` bigint NOT NULL,\n  `name` varchar(20),\n  `org_id` int NOT NULL,\n  PRIMARY KEY (`org_id`, `user_id`)\n);\n" +
+		"CREATE TABLE `log` (\n  `msg` text\n);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := schema["member"]
+	columns := member.PrimaryKeyColumns()
+	if len(columns) != 2 || columns[0] != member.Columns["org_id"] || columns[1] != member.Columns["user_id"] {
+		t.Errorf("expected primary key columns org_id,user_id, found %v", columns)
+	}
+	if columns := schema["log"].PrimaryKeyColumns(); columns != nil {
+		t.Errorf("expected no primary key columns, found %v", columns)
+	}
+}
+
+func TestColumnEffectiveCharset(t *testing.T) {
+	sqlStmt := "CREATE TABLE `user` (\n  `id` int,\n  `name` varchar(20),\n  `code` char(2)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema["user"]
+	name := user.Columns["name"]
+	if cs := name.EffectiveCharset(user); cs != "utf8mb4" {
+		t.Errorf("expected name to inherit charset utf8mb4, found %q", cs)
+	}
+	if co := name.EffectiveCollation(user); co != "utf8mb4_unicode_ci" {
+		t.Errorf("expected name to inherit collation utf8mb4_unicode_ci, found %q", co)
+	}
+	if cs := user.Columns["id"].EffectiveCharset(user); cs != "" {
+		t.Errorf("expected no charset for int column, found %q", cs)
+	}
+
+	code := user.Columns["code"]
+	code.Charset = "latin1"
+	if cs, co := code.EffectiveCharset(user), code.EffectiveCollation(user); cs != "latin1" || co != "" {
+		t.Errorf("expected explicit charset latin1 without collation, found %q %q", cs, co)
+	}
+	code.Charset, code.Collation = "", "ascii_bin"
+	if cs, co := code.EffectiveCharset(user), code.EffectiveCollation(user); cs != "ascii" || co != "ascii_bin" {
+		t.Errorf("expected charset ascii from collation ascii_bin, found %q %q", cs, co)
+	}
+}
@@ -0,0 +1,39 @@
+package sqlparser
+
+import (
+	"io"
+	"strings"
+)
+
+// SplitStatements splits a multi-statement dump on top-level semicolons.
+// Semicolons inside strings, quoted identifiers and comments do not end a
+// statement. The returned statements are trimmed and exclude the
+// terminating semicolon, statements consisting only of comments are dropped.
+func SplitStatements(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	input := string(data)
+	s := NewScanner(strings.NewReader(input))
+	var stmts []string
+	var start int
+	var significant bool
+	for {
+		off := s.Offset()
+		tok, _ := s.Scan()
+		switch tok {
+		case SEMI_COLON, EOF:
+			if significant {
+				stmts = append(stmts, strings.TrimSpace(input[start:off]))
+			}
+			if tok == EOF {
+				return stmts, nil
+			}
+			start, significant = s.Offset(), false
+		case WS, ANNOTATION:
+		default:
+			significant = true
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	dump := "-- dump header\nDROP TABLE IF EXISTS `a;b`;\n/* comment; with semicolon */;\n" +
+		"CREATE TABLE `a;b` (\n  `id` int COMMENT 'x;y'\n);\nINSERT INTO `a;b` VALUES (1)"
+	stmts, err := SplitStatements(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"-- dump header\nDROP TABLE IF EXISTS `a;b`",
+		"CREATE TABLE `a;b` (\n  `id` int COMMENT 'x;y'\n)",
+		"INSERT INTO `a;b` VALUES (1)",
+	}
+	if len(stmts) != len(expected) {
+		t.Fatalf("expected %d statements, found %d: %q", len(expected), len(stmts), stmts)
+	}
+	for i := range expected {
+		if stmts[i] != expected[i] {
+			t.Errorf("expected %q, found %q", expected[i], stmts[i])
+		}
+	}
+}
+
+func TestSplitStatementsUnterminatedString(t *testing.T) {
+	stmts, err := SplitStatements(strings.NewReader("SELECT 1; SELECT 'abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 2 || stmts[1] != "SELECT 'abc" {
+		t.Errorf("expected unterminated statement to be kept, found %q", stmts)
+	}
+}
@@ -1,4 +1,4 @@
---this is a comment
+-- this is a comment
 DROP TABLE IF EXISTS `user`;
 /* another comment */;
 CREATE TABLE `user` (
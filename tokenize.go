@@ -0,0 +1,87 @@
+package sqlparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Lexeme is a significant token along with the whitespace and comments
+// preceding it, so that the input can be rebuilt from its lexemes
+type Lexeme struct {
+	Tok     Token
+	Lit     string
+	Offset  int    // byte offset of the token in the input
+	Raw     string // the token as written, e.g. with its quotes
+	Leading string // whitespace and comments before the token, verbatim
+}
+
+// Tokenize scans the input into lexemes, attaching every whitespace run
+// and comment as leading trivia to the token that follows it. The last
+// lexeme is EOF, carrying the trivia at the end of the input.
+func Tokenize(r io.Reader) ([]Lexeme, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	input := string(data)
+	s := NewScanner(strings.NewReader(input))
+	var lexemes []Lexeme
+	var leading strings.Builder
+	for {
+		off := s.Offset()
+		tok, lit := s.Scan()
+		switch tok {
+		case WS, ANNOTATION:
+			leading.WriteString(input[off:s.Offset()])
+		default:
+			lexemes = append(lexemes, Lexeme{
+				Tok:     tok,
+				Lit:     lit,
+				Offset:  off,
+				Raw:     input[off:s.Offset()],
+				Leading: leading.String(),
+			})
+			if tok == EOF {
+				return lexemes, nil
+			}
+			leading.Reset()
+		}
+	}
+}
+
+// LexError is an ILLEGAL token found by LintTokens
+type LexError struct {
+	Line   int // 1-based
+	Col    int // 1-based, in runes
+	Offset int // byte offset of the token in the input
+	Lit    string
+	Msg    string
+}
+
+func (e LexError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// LintTokens scans the whole input without parsing it and returns every
+// ILLEGAL token with its position, e.g. for an editor to highlight
+func LintTokens(r io.Reader) []LexError {
+	s := NewScanner(r)
+	var errs []LexError
+	var reported error
+	for {
+		line, col := s.Pos()
+		off := s.Offset()
+		tok, lit := s.Scan()
+		switch tok {
+		case EOF:
+			return errs
+		case ILLEGAL:
+			msg := fmt.Sprintf("illegal token %q", lit)
+			if err := s.Err(); err != nil && err != reported { // e.g. unterminated comment
+				msg, reported = err.Error(), err
+			}
+			errs = append(errs, LexError{Line: line, Col: col, Offset: off, Lit: lit, Msg: msg})
+		}
+	}
+}
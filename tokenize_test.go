@@ -0,0 +1,62 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenizeTrivia(t *testing.T) {
+	sqlStmt := "-- users\nCREATE TABLE `user` (\n  /* primary */ `id` bigint(20)\n);\n"
+	lexemes, err := Tokenize(strings.NewReader(sqlStmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := lexemes[0]; first.Tok != CREATE || first.Leading != "-- users\n" {
+		t.Errorf("expected comment attached to CREATE, found %+v", first)
+	}
+	var id *Lexeme
+	for i := range lexemes {
+		if lexemes[i].Tok == IDENT && lexemes[i].Lit == "id" {
+			id = &lexemes[i]
+		}
+	}
+	if id == nil || id.Leading != "\n  /* primary */ " || id.Raw != "`id`" {
+		t.Errorf("expected block comment attached to `id`, found %+v", id)
+	}
+	if last := lexemes[len(lexemes)-1]; last.Tok != EOF || last.Leading != "\n" {
+		t.Errorf("expected EOF carrying the trailing newline, found %+v", last)
+	}
+
+	var buf strings.Builder
+	for _, l := range lexemes {
+		buf.WriteString(l.Leading + l.Raw)
+	}
+	if buf.String() != sqlStmt {
+		t.Errorf("expected lexemes to rebuild the input, found %q", buf.String())
+	}
+}
+
+func TestLintTokens(t *testing.T) {
+	sqlStmt := "CREATE TABLE `t` (\n  `a` int ~ é,\n  `b` text\n);\n? /* open"
+	errs := LintTokens(strings.NewReader(sqlStmt))
+	expected := []LexError{
+		{Line: 2, Col: 11, Offset: 29, Lit: "~", Msg: `illegal token "~"`},
+		{Line: 2, Col: 13, Offset: 31, Lit: "é", Msg: `illegal token "é"`},
+		{Line: 5, Col: 1, Offset: 49, Lit: "?", Msg: `illegal token "?"`},
+		{Line: 5, Col: 3, Offset: 51, Lit: "/*", Msg: "unterminated /* comment"},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, found %v", len(expected), errs)
+	}
+	for i, e := range expected {
+		if errs[i] != e {
+			t.Errorf("expected %+v, found %+v", e, errs[i])
+		}
+	}
+	if s := errs[0].Error(); s != `line 2, col 11: illegal token "~"` {
+		t.Errorf("unexpected error string %s", s)
+	}
+	if errs := LintTokens(strings.NewReader("CREATE TABLE `t` (`a` int);")); len(errs) != 0 {
+		t.Errorf("expected no errors, found %v", errs)
+	}
+}
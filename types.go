@@ -0,0 +1,163 @@
+package sqlparser
+
+import "strings"
+
+// TypeCategory is the storage category of a SQL datatype
+type TypeCategory int
+
+const (
+	UnknownType TypeCategory = iota
+	NumericType
+	StringType
+	BinaryType
+	TemporalType
+	SpatialType
+	JSONType
+)
+
+func (c TypeCategory) String() string {
+	switch c {
+	case NumericType:
+		return "numeric"
+	case StringType:
+		return "string"
+	case BinaryType:
+		return "binary"
+	case TemporalType:
+		return "temporal"
+	case SpatialType:
+		return "spatial"
+	case JSONType:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// TypeMeta describes what a SQL datatype accepts and how it is stored
+type TypeMeta struct {
+	Category TypeCategory
+	Sized    bool // accepts a size, e.g. varchar(255) or datetime(6)
+	Scaled   bool // accepts a scale after the size, e.g. double(10,2)
+	Unsigned bool // accepts the UNSIGNED attribute
+}
+
+var typeMeta = map[string]TypeMeta{
+	"bit":                {Category: NumericType, Sized: true},
+	"tinyint":            {Category: NumericType, Sized: true, Unsigned: true},
+	"smallint":           {Category: NumericType, Sized: true, Unsigned: true},
+	"int":                {Category: NumericType, Sized: true, Unsigned: true},
+	"bigint":             {Category: NumericType, Sized: true, Unsigned: true},
+	"float":              {Category: NumericType, Sized: true, Scaled: true, Unsigned: true},
+	"double":             {Category: NumericType, Sized: true, Scaled: true, Unsigned: true},
+	"decimal":            {Category: NumericType, Sized: true, Scaled: true, Unsigned: true},
+	"char":               {Category: StringType, Sized: true},
+	"varchar":            {Category: StringType, Sized: true},
+	"tinytext":           {Category: StringType},
+	"text":               {Category: StringType, Sized: true},
+	"mediumtext":         {Category: StringType},
+	"longtext":           {Category: StringType},
+	"tinyblob":           {Category: BinaryType},
+	"blob":               {Category: BinaryType, Sized: true},
+	"mediumblob":         {Category: BinaryType},
+	"longblob":           {Category: BinaryType},
+	"enum":               {Category: StringType},
+	"set":                {Category: StringType},
+	"geometry":           {Category: SpatialType},
+	"point":              {Category: SpatialType},
+	"linestring":         {Category: SpatialType},
+	"polygon":            {Category: SpatialType},
+	"multipoint":         {Category: SpatialType},
+	"multilinestring":    {Category: SpatialType},
+	"multipolygon":       {Category: SpatialType},
+	"geometrycollection": {Category: SpatialType},
+	"json":               {Category: JSONType},
+	"date":               {Category: TemporalType},
+	"time":               {Category: TemporalType, Sized: true},
+	"datetime":           {Category: TemporalType, Sized: true},
+	"timestamp":          {Category: TemporalType, Sized: true},
+}
+
+// typeSynonyms maps type synonyms to their canonical type
+var typeSynonyms = map[string]string{
+	"integer": "int",
+	"bool":    "tinyint",
+	"boolean": "tinyint",
+	"real":    "double",
+	"dec":     "decimal",
+	"numeric": "decimal",
+	"fixed":   "decimal",
+}
+
+// CanonicalType returns the canonical lower case name of a type, resolving
+// synonyms such as INTEGER to int and BOOL to tinyint
+func CanonicalType(typ string) string {
+	typ = strings.ToLower(typ)
+	if c, ok := typeSynonyms[typ]; ok {
+		return c
+	}
+	return typ
+}
+
+// TypeInfo returns the metadata of a type as stored in Column.Type, the
+// boolean is false for unknown types
+func TypeInfo(typ string) (TypeMeta, bool) {
+	meta, ok := typeMeta[CanonicalType(typ)]
+	return meta, ok
+}
+
+// Category returns the storage category of the column type, UnknownType
+// for types TypeInfo does not know
+func (c *Column) Category() TypeCategory {
+	meta, _ := TypeInfo(c.Type)
+	return meta.Category
+}
+
+// IsNumeric reports whether the column holds numbers
+func (c *Column) IsNumeric() bool { return c.Category() == NumericType }
+
+// IsString reports whether the column holds character strings
+func (c *Column) IsString() bool { return c.Category() == StringType }
+
+// IsTemporal reports whether the column holds dates or times
+func (c *Column) IsTemporal() bool { return c.Category() == TemporalType }
+
+// IsBinary reports whether the column holds byte strings
+func (c *Column) IsBinary() bool { return c.Category() == BinaryType }
+
+// defaultSizes holds the size MySQL assumes when a type omits it, the
+// display width for signed integers, the precision for decimal and the
+// length for bit and char. varchar has no default in MySQL, 255 is assumed.
+var defaultSizes = map[string]int{
+	"bit":      1,
+	"tinyint":  4,
+	"smallint": 6,
+	"int":      11,
+	"bigint":   20,
+	"decimal":  10,
+	"char":     1,
+	"varchar":  255,
+}
+
+// unsignedDefaultSizes holds the display widths of unsigned integers, one
+// less than signed ones as there is no minus sign, except for bigint
+var unsignedDefaultSizes = map[string]int{
+	"tinyint":  3,
+	"smallint": 5,
+	"int":      10,
+	"bigint":   20,
+}
+
+// EffectiveSize returns the size of the column, or the default size of
+// its type when the size was omitted. Types without a default size, e.g.
+// datetime whose fractional seconds precision defaults to 0, return 0.
+func (c *Column) EffectiveSize() int {
+	if c.HasSize {
+		return c.Size
+	}
+	typ := CanonicalType(c.Type)
+	if size, ok := unsignedDefaultSizes[typ]; ok && c.Unsigned {
+		return size
+	}
+	return defaultSizes[typ]
+}
@@ -0,0 +1,204 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeInfo(t *testing.T) {
+	expected := map[string]TypeMeta{
+		"int":       {Category: NumericType, Sized: true, Unsigned: true},
+		"DOUBLE":    {Category: NumericType, Sized: true, Scaled: true, Unsigned: true},
+		"varchar":   {Category: StringType, Sized: true},
+		"longtext":  {Category: StringType},
+		"date":      {Category: TemporalType},
+		"timestamp": {Category: TemporalType, Sized: true},
+	}
+	for typ, meta := range expected {
+		m, ok := TypeInfo(typ)
+		if !ok {
+			t.Errorf("expected metadata for %s, but not found", typ)
+			continue
+		}
+		if m != meta {
+			t.Errorf("%s: expected %+v, found %+v", typ, meta, m)
+		}
+	}
+	if _, ok := TypeInfo("nosuchtype"); ok {
+		t.Errorf("expected no metadata for unknown type")
+	}
+	// every parsed type must have metadata
+	for _, typ := range Type {
+		if _, ok := TypeInfo(typ); !ok {
+			t.Errorf("missing metadata for %s", typ)
+		}
+	}
+}
+
+func TestCanonicalTypes(t *testing.T) {
+	sqlStmt := "CREATE TABLE `t` (\n  `a` integer,\n  `b` dec(10),\n  `c` fixed,\n  `d` bool,\n  `e` boolean,\n  `f` real,\n  `g` int(11)\n);"
+	expected := map[string]Column{
+		"a": {Type: "int"},
+		"b": {Type: "decimal", Size: 10},
+		"c": {Type: "decimal"},
+		"d": {Type: "tinyint", Size: 1},
+		"e": {Type: "tinyint", Size: 1},
+		"f": {Type: "double"},
+		"g": {Type: "int", Size: 11},
+	}
+	p := NewParser(strings.NewReader(sqlStmt))
+	p.CanonicalTypes = true
+	schema, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, e := range expected {
+		c := schema["t"].Columns[name]
+		if c.Type != e.Type || c.Size != e.Size {
+			t.Errorf("column %s: expected %s(%d), found %s(%d)", name, e.Type, e.Size, c.Type, c.Size)
+		}
+	}
+
+	schema, err = NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := schema["t"].Columns["a"].Type; typ != "integer" {
+		t.Errorf("expected type integer without canonicalization, found %s", typ)
+	}
+}
+
+func TestColumnCategory(t *testing.T) {
+	sqlStmt := "CREATE TABLE `t` (\n  `a` bigint(20),\n  `b` double,\n  `c` varchar(20),\n  `d` longtext,\n  `e` datetime,\n  `f` date,\n  `g` blob\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]TypeCategory{
+		"a": NumericType,
+		"b": NumericType,
+		"c": StringType,
+		"d": StringType,
+		"e": TemporalType,
+		"f": TemporalType,
+		"g": BinaryType,
+	}
+	for name, category := range expected {
+		c := schema["t"].Columns[name]
+		if found := c.Category(); found != category {
+			t.Errorf("column %s: expected %v, found %v", name, category, found)
+		}
+		if c.IsNumeric() != (category == NumericType) || c.IsString() != (category == StringType) ||
+			c.IsTemporal() != (category == TemporalType) || c.IsBinary() != (category == BinaryType) {
+			t.Errorf("column %s: predicates disagree with category %v", name, category)
+		}
+	}
+	if category := (&Column{Type: "xml"}).Category(); category != UnknownType {
+		t.Errorf("expected unknown category, found %v", category)
+	}
+}
+
+func TestColumnEffectiveSize(t *testing.T) {
+	sqlStmt := "CREATE TABLE `t` (\n  `a` decimal,\n  `b` decimal(12),\n  `c` int,\n  `d` varchar,\n  `e` datetime,\n  `f` datetime(0),\n  `g` bigint(10),\n  `h` int unsigned,\n  `i` smallint unsigned,\n  `j` tinyint unsigned zerofill,\n  `k` bigint unsigned\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]struct {
+		hasSize bool
+		size    int
+	}{
+		"a": {false, 10},
+		"b": {true, 12},
+		"c": {false, 11},
+		"d": {false, 255},
+		"e": {false, 0},
+		"f": {true, 0},
+		"g": {true, 10},
+		"h": {false, 10},
+		"i": {false, 5},
+		"j": {false, 3},
+		"k": {false, 20},
+	}
+	for name, e := range expected {
+		c := schema["t"].Columns[name]
+		if c.HasSize != e.hasSize || c.EffectiveSize() != e.size {
+			t.Errorf("column %s: expected sized %v effective size %d, found %v %d", name, e.hasSize, e.size, c.HasSize, c.EffectiveSize())
+		}
+	}
+	if sql := schema["t"].Columns["f"].SQL(); sql != "`f` datetime(0)" {
+		t.Errorf("expected explicit size to be kept, found %s", sql)
+	}
+}
+
+func TestParserDecimalScale(t *testing.T) {
+	sqlStmt := "CREATE TABLE `t` (\n  `price` decimal(10,2) NOT NULL,\n  `amount` numeric(12, 4),\n  `total` decimal(10),\n  `ratio` double(8,3),\n  `n` int(11)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Column{
+		"price":  {Type: "decimal", Size: 10, Scale: 2},
+		"amount": {Type: "numeric", Size: 12, Scale: 4},
+		"total":  {Type: "decimal", Size: 10},
+		"ratio":  {Type: "double", Size: 8, Scale: 3},
+		"n":      {Type: "int", Size: 11},
+	}
+	for name, e := range expected {
+		c := schema["t"].Columns[name]
+		if c.Type != e.Type || c.Size != e.Size || c.Scale != e.Scale {
+			t.Errorf("column %s: expected %s(%d,%d), found %s(%d,%d)", name, e.Type, e.Size, e.Scale, c.Type, c.Size, c.Scale)
+		}
+	}
+	if sql := schema["t"].Columns["price"].SQL(); sql != "`price` decimal(10,2) NOT NULL" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+	if typ := CanonicalType("NUMERIC"); typ != "decimal" {
+		t.Errorf("expected numeric to canonicalize to decimal, found %s", typ)
+	}
+}
+
+func TestParserChar(t *testing.T) {
+	sqlStmt := "CREATE TABLE `country` (\n  `code` char(2) NOT NULL,\n  `flag` char,\n  `name` varchar(20)\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := schema["country"].Columns["code"]
+	if code.Type != "char" || code.Size != 2 || !code.IsString() {
+		t.Errorf("expected string column char(2), found %s(%d)", code.Type, code.Size)
+	}
+	flag := schema["country"].Columns["flag"]
+	if flag.Type != "char" || flag.HasSize || flag.EffectiveSize() != 1 {
+		t.Errorf("expected char defaulting to char(1), found %s sized %v effective %d", flag.Type, flag.HasSize, flag.EffectiveSize())
+	}
+	if sql := code.SQL(); sql != "`code` char(2) NOT NULL" {
+		t.Errorf("unexpected column SQL %s", sql)
+	}
+}
+
+func TestParserTextAndBlob(t *testing.T) {
+	sqlStmt := "CREATE TABLE `post` (\n  `a` tinytext,\n  `b` text NOT NULL,\n  `c` text(255),\n  `d` mediumtext,\n  `e` longtext,\n" +
+		"  `f` tinyblob,\n  `g` blob,\n  `h` mediumblob,\n  `i` LONGBLOB\n);"
+	schema, err := NewParser(strings.NewReader(sqlStmt)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Column{
+		"a": {Type: "tinytext"},
+		"b": {Type: "text"},
+		"c": {Type: "text", Size: 255},
+		"d": {Type: "mediumtext"},
+		"e": {Type: "longtext"},
+		"f": {Type: "tinyblob"},
+		"g": {Type: "blob"},
+		"h": {Type: "mediumblob"},
+		"i": {Type: "longblob"},
+	}
+	for name, e := range expected {
+		c := schema["post"].Columns[name]
+		if c.Type != e.Type || c.Size != e.Size {
+			t.Errorf("column %s: expected %s(%d), found %s(%d)", name, e.Type, e.Size, c.Type, c.Size)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks the table for definitions MySQL would reject. A strict
+// parser validates every table it parses.
+func (t *Table) Validate() error {
+	for _, name := range t.columnNames() {
+		if t.Columns[name].AutoIncr && !t.isFirstKeyColumn(name) {
+			return fmt.Errorf("table %q: auto increment column %q must be the first column of a key", t.Name, name)
+		}
+		if err := t.Columns[name].Validate(); err != nil {
+			return fmt.Errorf("table %q: %w", t.Name, err)
+		}
+	}
+	if err := t.validatePrefixes(); err != nil {
+		return err
+	}
+	return t.validateOptions()
+}
+
+// validatePrefixes rejects key prefix lengths on columns that are not
+// strings and prefixes longer than a sized CHAR or VARCHAR column
+func (t *Table) validatePrefixes() error {
+	for _, index := range t.sortedIndexes() {
+		for _, part := range index.Parts {
+			c := t.Columns[part.Column]
+			if part.Length == 0 || c == nil {
+				continue
+			}
+			switch category := c.Category(); {
+			case category != StringType && category != BinaryType && category != UnknownType:
+				return fmt.Errorf("table %q: index %q: prefix on %s column %q", t.Name, index.Name, category, c.Name)
+			case c.HasSize && (c.Type == "char" || c.Type == "varchar") && part.Length > c.Size:
+				return fmt.Errorf("table %q: index %q: prefix %d is longer than column %q", t.Name, index.Name, part.Length, c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the members of an ENUM or SET column: they must be
+// distinct, compared case-insensitively unless the column has a binary or
+// case-sensitive collation, and the default must be one of them. A SET
+// default may combine members and an ENUM default may be a member index.
+func (c *Column) Validate() error {
+	members := c.members()
+	if members == nil {
+		return nil
+	}
+	index := func(member string) int {
+		for i, m := range members {
+			if c.sameMember(m, member) {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, member := range members {
+		if index(member) != i {
+			return fmt.Errorf("column %q: duplicate %s member %s", c.Name, c.Type, quoteString(member))
+		}
+	}
+	switch v := c.Default.(type) {
+	case string:
+		if v == "null" {
+			return nil
+		}
+		values := []string{v}
+		if c.SetValues != nil {
+			if v == "" {
+				return nil
+			}
+			values = strings.Split(v, ",")
+		}
+		for _, value := range values {
+			if index(value) < 0 {
+				return fmt.Errorf("column %q: default %s is not a %s member", c.Name, quoteString(v), c.Type)
+			}
+		}
+	case int64:
+		if v < 0 || !c.memberNumber(uint64(v), len(members)) {
+			return fmt.Errorf("column %q: default %d is out of range for %s", c.Name, v, c.Type)
+		}
+	case uint64: // beyond int64, only a SET of 64 members reaches it
+		if !c.memberNumber(v, len(members)) {
+			return fmt.Errorf("column %q: default %d is out of range for %s", c.Name, v, c.Type)
+		}
+	}
+	return nil
+}
+
+// memberNumber reports whether n is the index of an ENUM member, counting
+// from 1, or the bitmask of members of a SET with count members
+func (c *Column) memberNumber(n uint64, count int) bool {
+	if c.SetValues != nil {
+		// 1<<64 is 0 for uint64 so the mask of 64 members is all ones
+		return n <= uint64(1)<<uint(count)-1
+	}
+	return n >= 1 && n <= uint64(count)
+}
+
+// sameMember compares ENUM and SET members the way the column collation does
+func (c *Column) sameMember(a, b string) bool {
+	collation := strings.ToLower(c.Collation)
+	if collation == "binary" || strings.HasSuffix(collation, "_bin") || strings.HasSuffix(collation, "_cs") {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
+
+// validateOptions rejects contradictory table options: COMPRESSION is
+// page compression, which InnoDB refuses on ROW_FORMAT=COMPRESSED tables
+func (t *Table) validateOptions() error {
+	var rowFormat, compression string
+	for k, v := range t.Extras {
+		switch strings.ToUpper(k) {
+		case "ROW_FORMAT":
+			rowFormat = v
+		case "COMPRESSION":
+			compression = v
+		}
+	}
+	if strings.EqualFold(rowFormat, "COMPRESSED") && compression != "" && !strings.EqualFold(compression, "none") {
+		return fmt.Errorf("table %q: COMPRESSION=%q conflicts with ROW_FORMAT=COMPRESSED", t.Name, compression)
+	}
+	return nil
+}
+
+// isFirstKeyColumn reports whether column leads any key usable by
+// AUTO_INCREMENT
+func (t *Table) isFirstKeyColumn(column string) bool {
+	for _, index := range t.Indexes {
+		if index.Kind.btree() && len(index.Parts) > 0 && index.Parts[0].Column == column {
+			return true
+		}
+	}
+	return false
+}
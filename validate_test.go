@@ -0,0 +1,123 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateAutoIncrement(t *testing.T) {
+	indexed := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n);"
+	unindexed := "CREATE TABLE `user` (\n  `id` bigint(20) NOT NULL AUTO_INCREMENT,\n  `name` varchar(20)\n);"
+
+	p := NewParser(strings.NewReader(indexed))
+	p.Strict = true
+	if _, err := p.Parse(); err != nil {
+		t.Errorf("expected indexed auto increment column to be valid, found %v", err)
+	}
+
+	schema, err := NewParser(strings.NewReader(unindexed)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema["user"].Validate(); err == nil {
+		t.Errorf("expected error for unindexed auto increment column")
+	}
+	p = NewParser(strings.NewReader(unindexed))
+	p.Strict = true
+	if _, err := p.Parse(); err == nil {
+		t.Errorf("expected strict parser to reject unindexed auto increment column")
+	}
+}
+
+func TestValidateConflictingOptions(t *testing.T) {
+	conflicting := "CREATE TABLE `user` (\n  `id` bigint(20)\n) ENGINE=InnoDB ROW_FORMAT=COMPRESSED COMPRESSION='zlib';"
+	if _, err := NewParser(strings.NewReader(conflicting)).Parse(); err != nil {
+		t.Errorf("expected lenient parser to accept conflicting options, found %v", err)
+	}
+	p := NewParser(strings.NewReader(conflicting))
+	p.Strict = true
+	if _, err := p.Parse(); err == nil {
+		t.Errorf("expected strict parser to reject ROW_FORMAT=COMPRESSED with COMPRESSION")
+	}
+
+	for _, options := range []string{"ROW_FORMAT=COMPRESSED COMPRESSION='none'", "ROW_FORMAT=DYNAMIC COMPRESSION='zlib'", "ROW_FORMAT=COMPRESSED"} {
+		p = NewParser(strings.NewReader("CREATE TABLE `user` (\n  `id` bigint(20)\n) ENGINE=InnoDB " + options + ";"))
+		p.Strict = true
+		if _, err := p.Parse(); err != nil {
+			t.Errorf("%s: expected no error, found %v", options, err)
+		}
+	}
+}
+
+func TestValidateEnumMembers(t *testing.T) {
+	tests := []struct {
+		column string
+		valid  bool
+	}{
+		{"`s` enum('a','b') DEFAULT 'b'", true},
+		{"`s` enum('a','b') DEFAULT 'B'", true},
+		{"`s` enum('a','b') DEFAULT NULL", true},
+		{"`s` enum('a','b') NOT NULL DEFAULT 2", true},
+		{"`s` set('a','b') DEFAULT 'a,b'", true},
+		{"`s` set('a','b') DEFAULT ''", true},
+		{"`s` set('a','b') DEFAULT 3", true},
+		{"`s` set(" + setMembers(64) + ") DEFAULT 1", true},
+		{"`s` set(" + setMembers(64) + ") DEFAULT 18446744073709551615", true},
+		{"`s` set(" + setMembers(63) + ") DEFAULT 9223372036854775808", false},
+		{"`s` enum('a','A') COLLATE utf8mb4_bin", true},
+		{"`s` enum('a','a')", false},
+		{"`s` set('x','y','X')", false},
+		{"`s` enum('a','b') DEFAULT 'c'", false},
+		{"`s` enum('a','b') DEFAULT ''", false},
+		{"`s` enum('a','b') DEFAULT 3", false},
+		{"`s` set('a','b') DEFAULT 'a,c'", false},
+		{"`s` set('a','b') DEFAULT 4", false},
+	}
+	for _, test := range tests {
+		sqlStmt := "CREATE TABLE `user` (\n  " + test.column + "\n);"
+		if _, err := ParseString(sqlStmt); err != nil {
+			t.Errorf("%s: expected lenient parser to accept, found %v", test.column, err)
+		}
+		p := NewParser(strings.NewReader(sqlStmt))
+		p.Strict = true
+		_, err := p.Parse()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected valid, found %v", test.column, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: expected strict parser to reject", test.column)
+		}
+	}
+}
+
+// setMembers returns n distinct quoted SET members
+func setMembers(n int) string {
+	var members []string
+	for i := 0; i < n; i++ {
+		members = append(members, fmt.Sprintf("'m%d'", i))
+	}
+	return strings.Join(members, ",")
+}
+
+func TestValidatePrefixLength(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"KEY `k` (`name`(20))", true},
+		{"KEY `k` (`body`(500))", true},
+		{"KEY `k` (`name`(21))", false},
+		{"KEY `k` (`id`(4))", false},
+	}
+	for _, test := range tests {
+		sqlStmt := "CREATE TABLE `post` (\n  `id` int,\n  `name` varchar(20),\n  `body` blob,\n  " + test.key + "\n);"
+		p := NewParser(strings.NewReader(sqlStmt))
+		p.Strict = true
+		_, err := p.Parse()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected valid, found %v", test.key, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: expected strict parser to reject", test.key)
+		}
+	}
+}